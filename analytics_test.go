@@ -0,0 +1,358 @@
+package fundhawk
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testRound returns a round of company in the given month, or undated if
+// year is 0.
+func testRound(company, code string, year, month int) *Round {
+	r := &Round{Code: code, Company: Company{Name: company, Permalink: company}}
+	if year != 0 {
+		r.Year, r.Month = &year, &month
+	}
+	return r
+}
+
+func office(city, state, country string, lat, lon float64) Office {
+	return Office{City: city, State: state, Country: country, Latitude: &lat, Longitude: &lon}
+}
+
+// analyticsDataset is a small portfolio with known answers:
+//
+//	widget    seed 2008-01 alpha* beta, a 2009-06 alpha beta gamma, b 2011-03 gamma
+//	gadget    seed 2008-03 beta, a 2011-06 alpha beta
+//	sprocket  a 2010-01 delta epsilon, b 2011-01 delta epsilon
+//
+// alpha's widget seed is flagged as its lead. Widget, a Palo Alto company,
+// was acquired in 2012 and gadget, in New York, went public in 2014, the year
+// it was also acquired. Nothing is known about sprocket.
+func analyticsDataset() *Dataset {
+	d := NewDataset()
+	d.MaxYear = 2015
+
+	rounds := map[string][]Investment{}
+	join := func(r *Round, lead string, vcs ...string) {
+		for _, vc := range vcs {
+			copied := *r
+			rounds[vc] = append(rounds[vc], Investment{Round: &copied, Lead: vc == lead})
+		}
+	}
+	join(testRound("widget", "seed", 2008, 1), "alpha", "alpha", "beta")
+	join(testRound("widget", "a", 2009, 6), "", "alpha", "beta", "gamma")
+	join(testRound("widget", "b", 2011, 3), "", "gamma")
+	join(testRound("gadget", "seed", 2008, 3), "", "beta")
+	join(testRound("gadget", "a", 2011, 6), "", "alpha", "beta")
+	join(testRound("sprocket", "a", 2010, 1), "", "delta", "epsilon")
+	join(testRound("sprocket", "b", 2011, 1), "", "delta", "epsilon")
+
+	offices := map[string][]Office{
+		"alpha": {office("San Francisco", "CA", "USA", 37.7749, -122.4194)},
+		"beta":  {{City: "New York", State: "NY", Country: "USA"}},
+	}
+	for vc, investments := range rounds {
+		d.Add(&VC{Name: vc, Permalink: vc, Offices: offices[vc], Investments: investments})
+	}
+
+	acquired, public := 2012, 2014
+	price, valuation := 100e6, 500e6
+	d.AddCompany(&CompanyProfile{
+		Name: "widget", Permalink: "widget", Category: "web", TagList: "saas, Mobile",
+		Offices:     []Office{office("Palo Alto", "CA", "USA", 37.4419, -122.143)},
+		Acquisition: &Acquisition{Price: &price, Year: &acquired},
+	})
+	d.AddCompany(&CompanyProfile{
+		Name: "gadget", Permalink: "gadget", Category: "mobile", TagList: "mobile",
+		Offices:     []Office{office("New York", "NY", "USA", 40.7128, -74.006)},
+		Acquisition: &Acquisition{Year: &public},
+		IPO:         &IPO{Valuation: &valuation, Year: &public, Symbol: "GDGT"},
+	})
+
+	d.Calculate()
+	return d
+}
+
+func TestCalculateExits(t *testing.T) {
+	d := analyticsDataset()
+	for _, tt := range []struct {
+		vc          string
+		exits       int
+		yearsToExit IntSlice
+		rates       []BucketedInt
+		notable     []string
+	}{
+		// entered widget at seed in 2008 and gadget at a in 2011
+		{"alpha", 2, IntSlice{3, 4}, []BucketedInt{{"Seed", 100}, {"A", 100}}, []string{"gadget", "widget"}},
+		{"gamma", 1, IntSlice{3}, []BucketedInt{{"A", 100}}, []string{"widget"}},
+		{"delta", 0, IntSlice{}, []BucketedInt{{"A", 0}}, []string{}},
+	} {
+		vc := d.VCs[tt.vc]
+		notable := make([]string, 0)
+		for _, e := range vc.NotableExits {
+			notable = append(notable, e.Company.Permalink)
+		}
+		if vc.Exits != tt.exits || !reflect.DeepEqual(vc.YearsToExit, tt.yearsToExit) ||
+			!reflect.DeepEqual(vc.ExitRateDist.Buckets, tt.rates) || !reflect.DeepEqual(notable, tt.notable) {
+			t.Errorf("%s: got %d exits %v years %v rates %v, want %d %v %v %v", tt.vc,
+				vc.Exits, notable, vc.YearsToExit, vc.ExitRateDist.Buckets, tt.exits, tt.notable, tt.yearsToExit, tt.rates)
+		}
+	}
+
+	// an IPO in the year of the acquisition wins
+	if e := d.VCs["alpha"].NotableExits[0]; !e.IPO || e.Symbol != "GDGT" || e.Value != 500e6 {
+		t.Errorf("gadget exit: got %+v, want the IPO", e)
+	}
+}
+
+func TestCalculateLeads(t *testing.T) {
+	d := analyticsDataset()
+	leads := map[string]string{
+		// flagged by the source
+		"widget:2008:seed": "alpha",
+		// no firm joined an earlier A, alpha and beta were first seen in the
+		// seed, and alpha comes first
+		"widget:2009:a": "alpha",
+		// both joined widget's A before, but beta was first seen at gadget
+		"gadget:2011:a": "beta",
+		"widget:2011:b": "gamma",
+	}
+	for _, c := range d.Companies {
+		for _, r := range c.Rounds {
+			want, ok := leads[r.ID]
+			if !ok {
+				continue
+			}
+			if r.Lead == nil || r.Lead.Permalink != want || r.LeadInferred != (r.ID != "widget:2008:seed") {
+				t.Errorf("%s: got lead %v inferred %t, want %s", r.ID, r.Lead, r.LeadInferred, want)
+			}
+		}
+	}
+
+	alpha := d.VCs["alpha"]
+	if alpha.RoundsJoined != 3 || alpha.RoundsLed != 2 || alpha.LeadRate != 67 {
+		t.Errorf("alpha: got %d of %d led (%d%%), want 2 of 3 (67%%)", alpha.RoundsLed, alpha.RoundsJoined, alpha.LeadRate)
+	}
+	if want := []BucketedInt{{"Seed", 100}, {"A", 50}}; !reflect.DeepEqual(alpha.LeadRateDist.Buckets, want) {
+		t.Errorf("alpha lead rates: got %v, want %v", alpha.LeadRateDist.Buckets, want)
+	}
+	followers := make(map[string]int64)
+	for _, p := range alpha.Followers {
+		followers[p.VC.Permalink] = p.Percentage
+	}
+	if want := map[string]int64{"beta": 100, "gamma": 50}; !reflect.DeepEqual(followers, want) {
+		t.Errorf("alpha followers: got %v, want %v", followers, want)
+	}
+	if beta := d.VCs["beta"]; beta.RoundsJoined != 4 || beta.RoundsLed != 2 {
+		t.Errorf("beta: got %d of %d led, want 2 of 4", beta.RoundsLed, beta.RoundsJoined)
+	}
+}
+
+func TestCalculateFollowOns(t *testing.T) {
+	d := analyticsDataset()
+
+	alpha := d.VCs["alpha"]
+	if alpha.FollowOnRate != 100 || alpha.AvgRoundsHeld != 1.5 {
+		t.Errorf("alpha: got follow-on rate %d, %v rounds held, want 100 and 1.5", alpha.FollowOnRate, alpha.AvgRoundsHeld)
+	}
+	want := StageMatrix{
+		Stages: []string{"Seed", "A", "B"},
+		Cells: [][]StageCell{
+			{{}, {1, 1, 100}, {}},
+			{{}, {}, {1, 0, 0}},
+			{{}, {}, {}},
+		},
+	}
+	if !reflect.DeepEqual(alpha.StageMatrix, want) {
+		t.Errorf("alpha stage matrix: got %+v, want %+v", alpha.StageMatrix, want)
+	}
+
+	beta := d.VCs["beta"]
+	if c := beta.StageMatrix.Cells[0][1]; c != (StageCell{2, 2, 100}) {
+		t.Errorf("beta seed to A: got %+v, want 2 of 2", c)
+	}
+	// gamma entered widget at A and followed on in B
+	if want := []BucketedInt{{"A", 100}}; !reflect.DeepEqual(d.VCs["gamma"].FollowOnDist.Buckets, want) {
+		t.Errorf("gamma follow-ons: got %v, want %v", d.VCs["gamma"].FollowOnDist.Buckets, want)
+	}
+}
+
+func TestCalculateProgression(t *testing.T) {
+	d := analyticsDataset()
+	for _, tt := range []struct {
+		vc     string
+		months IntSlice
+		cohort int
+		rate   int64
+	}{
+		{"alpha", IntSlice{17, 21}, 1, 100},
+		// gadget took 39 months from seed to A
+		{"beta", IntSlice{17, 21, 39}, 2, 50},
+		{"delta", IntSlice{12}, 0, 0},
+	} {
+		vc := d.VCs[tt.vc]
+		if !reflect.DeepEqual(vc.MonthsToNextRound, tt.months) || vc.GraduationCohort != tt.cohort || vc.GraduationRate != tt.rate {
+			t.Errorf("%s: got months %v, %d%% of %d graduated, want %v, %d%% of %d", tt.vc,
+				vc.MonthsToNextRound, vc.GraduationRate, vc.GraduationCohort, tt.months, tt.rate, tt.cohort)
+		}
+	}
+}
+
+func TestCalculateSectors(t *testing.T) {
+	d := analyticsDataset()
+
+	alpha := d.VCs["alpha"]
+	if want := []BucketedInt{{"Mobile", 1}, {"Web", 1}}; !reflect.DeepEqual(alpha.SectorDist.Buckets, want) {
+		t.Errorf("alpha sectors: got %v, want %v", alpha.SectorDist.Buckets, want)
+	}
+	if want := []BucketedInt{{"mobile", 2}, {"saas", 1}}; !reflect.DeepEqual(alpha.TagDist.Buckets, want) {
+		t.Errorf("alpha tags: got %v, want %v", alpha.TagDist.Buckets, want)
+	}
+	// companies without a profile have no sector
+	if b := d.VCs["delta"].SectorDist.Buckets; len(b) != 0 {
+		t.Errorf("delta sectors: got %v, want none", b)
+	}
+}
+
+func TestCalculateGeography(t *testing.T) {
+	d := analyticsDataset()
+	for _, tt := range []struct {
+		vc        string
+		located   int
+		localRate int64
+		metros    []BucketedInt
+	}{
+		// Palo Alto is within reach of San Francisco
+		{"alpha", 3, 67, []BucketedInt{{"Palo Alto, CA", 67}, {"New York, NY", 33}}},
+		// beta's office has no coordinates, so only the same city counts
+		{"beta", 4, 50, []BucketedInt{{"New York, NY", 50}, {"Palo Alto, CA", 50}}},
+		{"gamma", 2, 0, []BucketedInt{{"Palo Alto, CA", 100}}},
+		{"delta", 0, 0, []BucketedInt{}},
+	} {
+		vc := d.VCs[tt.vc]
+		if vc.LocatedInvestments != tt.located || vc.LocalRate != tt.localRate || !reflect.DeepEqual(vc.MetroDist.Buckets, tt.metros) {
+			t.Errorf("%s: got %d located, %d%% local, metros %v, want %d, %d%%, %v", tt.vc,
+				vc.LocatedInvestments, vc.LocalRate, vc.MetroDist.Buckets, tt.located, tt.localRate, tt.metros)
+		}
+	}
+
+	alpha := d.VCs["alpha"]
+	if want := []BucketedInt{{"USA", 100}}; !reflect.DeepEqual(alpha.CountryDist.Buckets, want) {
+		t.Errorf("alpha countries: got %v, want %v", alpha.CountryDist.Buckets, want)
+	}
+	if len(alpha.MapDots) != 2 || alpha.MapDots[0].Label != "widget" {
+		t.Errorf("alpha map: got %+v, want widget's dot first", alpha.MapDots)
+	}
+}
+
+func TestCalculateClusters(t *testing.T) {
+	d := analyticsDataset()
+
+	got := make(map[string][]string)
+	for _, c := range d.Clusters {
+		members := make([]string, 0)
+		for _, vc := range c.Members {
+			if vc.Cluster != c {
+				t.Errorf("%s: not linked to its cluster %s", vc.Permalink, c.ID)
+			}
+			members = append(members, vc.Permalink)
+		}
+		sort.Strings(members)
+		got[c.ID] = members
+	}
+	want := map[string][]string{
+		"alpha": {"alpha", "beta", "gamma"},
+		"delta": {"delta", "epsilon"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got clusters %v, want %v", got, want)
+	}
+
+	companies := make([]string, 0)
+	for _, cc := range d.Clusters[0].Companies {
+		companies = append(companies, cc.Company.Permalink)
+	}
+	if want := []string{"widget", "gadget"}; !reflect.DeepEqual(companies, want) {
+		t.Errorf("alpha cluster companies: got %v, want %v", companies, want)
+	}
+
+	// only coinvestments in the window count
+	d.ClusterFrom = 2011
+	d.calculateClusters()
+	for _, c := range d.Clusters {
+		if c.ID == "alpha" && len(c.Members) != 2 {
+			t.Errorf("clusters from 2011: got %s cluster of %d, want alpha and beta", c.ID, len(c.Members))
+		}
+	}
+}
+
+func TestCompanyRoundsUndatedLast(t *testing.T) {
+	rounds := companyRounds{
+		{Round: *testRound("widget", "b", 2011, 3)},
+		{Round: *testRound("widget", "angel", 0, 0)},
+		{Round: *testRound("widget", "a", 2009, 6)},
+		{Round: *testRound("widget", "seed", 2009, 1)},
+	}
+	for i := range rounds {
+		rounds[i].Round.setDate()
+	}
+	sort.Sort(rounds)
+
+	codes := make([]string, 0, len(rounds))
+	for _, r := range rounds {
+		codes = append(codes, r.Round.Code)
+	}
+	if want := []string{"seed", "a", "b", "angel"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("got %v, want %v", codes, want)
+	}
+}
+
+func TestCalculateFollowOnsUndated(t *testing.T) {
+	d := NewDataset()
+	d.Add(&VC{Permalink: "alpha", Investments: []Investment{
+		{Round: testRound("widget", "a", 0, 0)},
+		{Round: testRound("widget", "b", 2011, 3)},
+	}})
+	d.Add(&VC{Permalink: "beta", Investments: []Investment{
+		{Round: testRound("widget", "seed", 2009, 1)},
+	}})
+	d.Calculate()
+
+	// the undated A is not taken for the round before alpha's B, nor for
+	// the one after beta's seed
+	if m := d.VCs["alpha"].StageMatrix; len(m.Stages) != 0 {
+		t.Errorf("alpha: got transitions %+v, want none", m)
+	}
+	if m := d.VCs["beta"].StageMatrix; !reflect.DeepEqual(m.Stages, []string{"Seed", "B"}) || m.Cells[0][1].Rounds != 1 {
+		t.Errorf("beta: got transitions %+v, want seed to B", m)
+	}
+}
+
+func TestCalculateSimilar(t *testing.T) {
+	d := analyticsDataset()
+	for vc, want := range map[string][]string{
+		"alpha": {"beta", "gamma"},
+		// alpha and beta also joined Series A rounds, but not sprocket's
+		"delta": {"epsilon"},
+	} {
+		got := make([]string, 0)
+		for _, f := range d.VCs[vc].Similar {
+			got = append(got, f.VC.Permalink)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got similar firms %v, want %v", vc, got, want)
+		}
+	}
+}
+
+func TestCalculateCentralityPercentile(t *testing.T) {
+	d := analyticsDataset()
+	// alpha and beta, and delta and epsilon, are tied with each other
+	for vc, want := range map[string]int64{"alpha": 60, "beta": 60, "delta": 20, "epsilon": 20, "gamma": 0} {
+		if got := d.VCs[vc].Centrality.Percentile; got != want {
+			t.Errorf("%s: got percentile %d, want %d", vc, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package fundhawk
+
+import (
+	"sort"
+	"strconv"
+)
+
+// APIVersion is the version of the JSON schema of the api/ files. It changes
+// whenever a field is removed or changes meaning; new fields may be added
+// without a new version.
+const APIVersion = 1
+
+// APIPageSize is the number of firms on each page of the api/firms.json
+// listing.
+const APIPageSize = 500
+
+// APIFirm is the schema of api/firms/<permalink>.json. Rates and shares are
+// whole percentages and amounts are in USD.
+type APIFirm struct {
+	Version   int    `json:"version"`
+	Permalink string `json:"permalink"`
+	Name      string `json:"name"`
+	Homepage  string `json:"homepage_url,omitempty"`
+	Page      string `json:"page"`
+
+	Companies      int     `json:"companies"`
+	Investments    int     `json:"investments"`
+	RoundsLed      int     `json:"rounds_led"`
+	Exits          int     `json:"exits"`
+	LeadRate       int64   `json:"lead_rate"`
+	FollowOnRate   int64   `json:"follow_on_rate"`
+	GraduationRate int64   `json:"graduation_rate"`
+	LocalRate      int64   `json:"local_rate"`
+	AvgRoundsHeld  float64 `json:"avg_rounds_held"`
+
+	// Stats summarise the companies and rounds per year and the round
+	// sizes and even per-investor shares of the firm's rounds.
+	Stats map[string]APIStats `json:"stats"`
+
+	// Distributions are the charts of the firm page, keyed by name.
+	Distributions map[string]BucketedInts `json:"distributions"`
+	RoundsByYear  map[int]int64           `json:"rounds_by_year"`
+
+	// Percentiles rank the firm's headline metrics among its peers, keyed
+	// by metric name.
+	Percentiles map[string]*PeerRank `json:"percentiles"`
+
+	Centrality  Centrality      `json:"centrality"`
+	Cluster     *APICluster     `json:"cluster"`
+	Similar     []APISimilar    `json:"similar"`
+	Portfolio   []APICompany    `json:"portfolio"`
+	Coinvestors []APICoinvestor `json:"coinvestors"`
+}
+
+type APIStats struct {
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+}
+
+func apiStats(s IntSlice) APIStats {
+	return APIStats{First(s), Last(s), Median(s), RoundFloat(Mean(s), 2)}
+}
+
+type APICluster struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type APISimilar struct {
+	Permalink       string `json:"permalink"`
+	Name            string `json:"name"`
+	Score           int64  `json:"score"`
+	SharedCompanies int    `json:"shared_companies"`
+}
+
+// APICompany is a portfolio company: the rounds the firm joined, their total
+// size and the firm's even share of it, also as a percentage of everything
+// the company raised.
+type APICompany struct {
+	Permalink       string `json:"permalink"`
+	Name            string `json:"name"`
+	Rounds          int    `json:"rounds"`
+	Raised          int64  `json:"raised"`
+	RaisedShare     int64  `json:"raised_share"`
+	SharePercentage int    `json:"share_percentage"`
+}
+
+// APICoinvestor is a firm that invested in the same rounds from the
+// Dataset's MinYear to MaxYear. Percentage is the share of the other firm's
+// rounds in those years, and is only set if they coinvested more than once.
+type APICoinvestor struct {
+	Permalink  string `json:"permalink"`
+	Name       string `json:"name"`
+	Rounds     int    `json:"rounds"`
+	Percentage int64  `json:"percentage"`
+	FirstYear  int    `json:"first_year"`
+	LastYear   int    `json:"last_year"`
+}
+
+// APIFirmList is the schema of a page of the firm listing: api/firms.json
+// for the first page and api/firms-<n>.json for the next ones. Next is the
+// path of the next page, or empty on the last one.
+type APIFirmList struct {
+	Version int            `json:"version"`
+	Page    int            `json:"page"`
+	Pages   int            `json:"pages"`
+	Total   int            `json:"total"`
+	Next    string         `json:"next,omitempty"`
+	Firms   []APIFirmEntry `json:"firms"`
+}
+
+type APIFirmEntry struct {
+	Permalink   string `json:"permalink"`
+	Name        string `json:"name"`
+	Companies   int    `json:"companies"`
+	Investments int    `json:"investments"`
+	URL         string `json:"url"`
+}
+
+// APIFirmPath is the path of the firm's JSON in the site.
+func APIFirmPath(permalink string) string {
+	return "api/firms/" + permalink + ".json"
+}
+
+// APIFirmListPath is the path of the nth page of the firm listing.
+func APIFirmListPath(page int) string {
+	if page == 1 {
+		return "api/firms.json"
+	}
+	return "api/firms-" + strconv.Itoa(page) + ".json"
+}
+
+// API returns the firm as an APIFirm.
+func (vc *VC) API() *APIFirm {
+	f := &APIFirm{
+		Version:        APIVersion,
+		Permalink:      vc.Permalink,
+		Name:           vc.Name,
+		Page:           "firms/" + vc.Permalink + ".html",
+		Companies:      vc.TotalCompanies,
+		Investments:    len(vc.Investments),
+		RoundsLed:      vc.RoundsLed,
+		Exits:          vc.Exits,
+		LeadRate:       vc.LeadRate,
+		FollowOnRate:   vc.FollowOnRate,
+		GraduationRate: vc.GraduationRate,
+		LocalRate:      vc.LocalRate,
+		AvgRoundsHeld:  RoundFloat(vc.AvgRoundsHeld, 2),
+		Stats: map[string]APIStats{
+			"companies_per_year": apiStats(vc.YearCompanySet),
+			"rounds_per_year":    apiStats(vc.YearRoundSet),
+			"round_sizes":        apiStats(vc.RoundSizes),
+			"round_shares":       apiStats(vc.RoundShares),
+		},
+		Distributions: map[string]BucketedInts{
+			"series":               vc.SeriesDist,
+			"rounds_per_company":   vc.RoundCountDist,
+			"round_size":           vc.RaiseDist,
+			"round_share":          vc.ShareDist,
+			"coinvestors_by_round": vc.InvestorRoundDist,
+			"lead_rate":            vc.LeadRateDist,
+			"follow_on_rate":       vc.FollowOnDist,
+			"exit_rate":            vc.ExitRateDist,
+			"months_to_next_round": vc.NextRoundDist,
+			"sector":               vc.SectorDist,
+			"tag":                  vc.TagDist,
+			"country":              vc.CountryDist,
+			"metro":                vc.MetroDist,
+		},
+		RoundsByYear: vc.RoundsByYear,
+		Percentiles:  vc.PeerRanks,
+		Centrality:   vc.Centrality,
+		Similar:      make([]APISimilar, 0, len(vc.Similar)),
+		Portfolio:    make([]APICompany, 0, len(vc.CompanyList)),
+		Coinvestors:  make([]APICoinvestor, 0, len(vc.Partners)),
+	}
+	if vc.URL != nil {
+		f.Homepage = *vc.URL
+	}
+	if vc.Cluster != nil {
+		f.Cluster = &APICluster{vc.Cluster.ID, vc.Cluster.Name}
+	}
+	for _, s := range vc.Similar {
+		f.Similar = append(f.Similar, APISimilar{s.VC.Permalink, s.VC.Name, s.Score, s.SharedCompanies})
+	}
+	for _, c := range vc.CompanyList {
+		f.Portfolio = append(f.Portfolio, APICompany{c.Company.Permalink, c.Company.Name, c.Rounds, c.Raised, c.RaisedShare, c.SharePercentage})
+	}
+	for v, p := range vc.Partners {
+		f.Coinvestors = append(f.Coinvestors, APICoinvestor{v.Permalink, v.Name, p.Rounds, p.Percentage, p.FirstYear, p.LastYear})
+	}
+	sort.Sort(apiCoinvestors(f.Coinvestors))
+	return f
+}
+
+type apiCoinvestors []APICoinvestor
+
+func (a apiCoinvestors) Len() int      { return len(a) }
+func (a apiCoinvestors) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a apiCoinvestors) Less(i, j int) bool {
+	if a[i].Rounds != a[j].Rounds {
+		return a[i].Rounds > a[j].Rounds
+	}
+	return a[i].Permalink < a[j].Permalink
+}
+
+// APIFirmList splits the firms into the pages of the api/firms.json
+// listing, ordered by permalink.
+func (d *Dataset) APIFirmList() []*APIFirmList {
+	vcs := make([]*VC, 0, len(d.VCs))
+	for _, vc := range d.VCs {
+		vcs = append(vcs, vc)
+	}
+	sort.Sort(vcsByPermalink(vcs))
+
+	pages := (len(vcs) + APIPageSize - 1) / APIPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	list := make([]*APIFirmList, 0, pages)
+	for page := 1; page <= pages; page++ {
+		l := &APIFirmList{Version: APIVersion, Page: page, Pages: pages, Total: len(vcs), Firms: make([]APIFirmEntry, 0, APIPageSize)}
+		if page < pages {
+			l.Next = APIFirmListPath(page + 1)
+		}
+		for _, vc := range vcs[(page-1)*APIPageSize:] {
+			if len(l.Firms) == APIPageSize {
+				break
+			}
+			l.Firms = append(l.Firms, APIFirmEntry{vc.Permalink, vc.Name, vc.TotalCompanies, len(vc.Investments), APIFirmPath(vc.Permalink)})
+		}
+		list = append(list, l)
+	}
+	return list
+}
@@ -0,0 +1,246 @@
+package fundhawk
+
+import (
+	"math"
+	"sort"
+)
+
+// Centrality is a firm's position in the coinvestment network.
+type Centrality struct {
+	// Degree is the number of firms it has coinvested with, and
+	// WeightedDegree the number of coinvestments with them.
+	Degree         int `json:"degree"`
+	WeightedDegree int `json:"weighted_degree"`
+
+	// Betweenness is the share of shortest paths between two other firms
+	// that pass through this one, from 0 to 1.
+	Betweenness float64 `json:"betweenness"`
+	PageRank    float64 `json:"pagerank"`
+
+	// Clustering is the share of the firm's coinvestors that have also
+	// coinvested with each other.
+	Clustering float64 `json:"clustering"`
+
+	// Percentile is the percentage of firms with a lower PageRank.
+	Percentile int64 `json:"percentile"`
+}
+
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-10
+
+	// MostConnected is how many firms the leaderboard lists.
+	MostConnected = 250
+)
+
+// network is the coinvestment graph as adjacency lists over firm indices.
+type network struct {
+	vcs     []*VC
+	adj     [][]int
+	weights [][]int
+}
+
+func newNetwork(vcs map[string]*VC) *network {
+	n := &network{vcs: make([]*VC, 0, len(vcs))}
+	for _, vc := range vcs {
+		n.vcs = append(n.vcs, vc)
+	}
+	sort.Sort(vcsByPermalink(n.vcs))
+
+	index := make(map[*VC]int, len(n.vcs))
+	for i, vc := range n.vcs {
+		index[vc] = i
+	}
+	n.adj = make([][]int, len(n.vcs))
+	n.weights = make([][]int, len(n.vcs))
+	for i, vc := range n.vcs {
+		for v, p := range vc.Partners {
+			if j, ok := index[v]; ok {
+				n.adj[i] = append(n.adj[i], j)
+				n.weights[i] = append(n.weights[i], p.Rounds)
+			}
+		}
+	}
+	return n
+}
+
+type vcsByPermalink []*VC
+
+func (v vcsByPermalink) Len() int           { return len(v) }
+func (v vcsByPermalink) Less(i, j int) bool { return v[i].Permalink < v[j].Permalink }
+func (v vcsByPermalink) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+// betweenness is Brandes' algorithm over the unweighted graph, normalised
+// by the number of pairs of other firms. It runs a breadth-first search from
+// every firm, so it takes O(V·E) time for V firms and E coinvestor pairs and
+// is the slowest step of Calculate on the full CrunchBase data.
+func (n *network) betweenness() []float64 {
+	size := len(n.vcs)
+	cb := make([]float64, size)
+	sigma := make([]float64, size)
+	dist := make([]int, size)
+	delta := make([]float64, size)
+	pred := make([][]int, size)
+	stack := make([]int, 0, size)
+	queue := make([]int, 0, size)
+
+	for s := 0; s < size; s++ {
+		for i := range sigma {
+			sigma[i], dist[i], delta[i], pred[i] = 0, -1, 0, pred[i][:0]
+		}
+		sigma[s], dist[s] = 1, 0
+		stack, queue = stack[:0], append(queue[:0], s)
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range n.adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					pred[w] = append(pred[w], v)
+				}
+			}
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range pred[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				cb[w] += delta[w]
+			}
+		}
+	}
+
+	// every pair was counted from both ends
+	if size > 2 {
+		norm := float64((size - 1) * (size - 2))
+		for i := range cb {
+			cb[i] /= norm
+		}
+	}
+	return cb
+}
+
+// pageRank is PageRank over the graph with edges weighted by coinvestments.
+// Firms without coinvestors spread their rank evenly.
+func (n *network) pageRank() []float64 {
+	size := len(n.vcs)
+	if size == 0 {
+		return nil
+	}
+	total := make([]float64, size)
+	for i, ws := range n.weights {
+		for _, w := range ws {
+			total[i] += float64(w)
+		}
+	}
+
+	pr := make([]float64, size)
+	for i := range pr {
+		pr[i] = 1 / float64(size)
+	}
+	next := make([]float64, size)
+	for iter := 0; iter < pageRankIterations; iter++ {
+		var dangling float64
+		for i := range pr {
+			if total[i] == 0 {
+				dangling += pr[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(size) + pageRankDamping*dangling/float64(size)
+		for i := range next {
+			next[i] = base
+		}
+		for i, js := range n.adj {
+			for k, j := range js {
+				next[j] += pageRankDamping * pr[i] * float64(n.weights[i][k]) / total[i]
+			}
+		}
+
+		var diff float64
+		for i := range pr {
+			diff += math.Abs(next[i] - pr[i])
+		}
+		pr, next = next, pr
+		if diff < pageRankTolerance {
+			break
+		}
+	}
+	return pr
+}
+
+// clustering is the local clustering coefficient of every firm.
+func (n *network) clustering() []float64 {
+	neighbours := make([]map[int]bool, len(n.vcs))
+	for i, js := range n.adj {
+		neighbours[i] = make(map[int]bool, len(js))
+		for _, j := range js {
+			neighbours[i][j] = true
+		}
+	}
+
+	cc := make([]float64, len(n.vcs))
+	for i, js := range n.adj {
+		k := len(js)
+		if k < 2 {
+			continue
+		}
+		var links int
+		for a := 0; a < k; a++ {
+			for b := a + 1; b < k; b++ {
+				if neighbours[js[a]][js[b]] {
+					links++
+				}
+			}
+		}
+		cc[i] = float64(links) / float64(k*(k-1)/2)
+	}
+	return cc
+}
+
+// calculateCentrality sets the Centrality of every firm and ranks the firms
+// for the leaderboard.
+func (d *Dataset) calculateCentrality() {
+	n := newNetwork(d.VCs)
+	bc := n.betweenness()
+	pr := n.pageRank()
+	cc := n.clustering()
+
+	for i, vc := range n.vcs {
+		c := Centrality{Degree: len(n.adj[i]), Betweenness: bc[i], PageRank: pr[i], Clustering: cc[i]}
+		for _, w := range n.weights[i] {
+			c.WeightedDegree += w
+		}
+		vc.Centrality = c
+	}
+
+	d.Connected = append(make([]*VC, 0, len(n.vcs)), n.vcs...)
+	sort.Stable(byPageRank(d.Connected))
+	// firms tied with each other are not below one another, so each run of
+	// ties gets the count of firms after it
+	for i := 0; i < len(d.Connected); {
+		j := i + 1
+		for j < len(d.Connected) && d.Connected[j].Centrality.PageRank == d.Connected[i].Centrality.PageRank {
+			j++
+		}
+		for _, vc := range d.Connected[i:j] {
+			vc.Centrality.Percentile = Percentage(len(d.Connected), len(d.Connected)-j)
+		}
+		i = j
+	}
+	if len(d.Connected) > MostConnected {
+		d.Connected = d.Connected[:MostConnected]
+	}
+}
+
+type byPageRank []*VC
+
+func (v byPageRank) Len() int           { return len(v) }
+func (v byPageRank) Less(i, j int) bool { return v[i].Centrality.PageRank > v[j].Centrality.PageRank }
+func (v byPageRank) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
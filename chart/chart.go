@@ -0,0 +1,337 @@
+// Package chart renders bar, stacked bar, line and histogram charts as
+// self-contained SVG, with every style inline so the charts also display in
+// emails and PDFs.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	// Bar draws a bar per label and series, the series side by side.
+	Bar Kind = iota
+	// StackedBar stacks the series of each label into one bar.
+	StackedBar
+	// Line draws a line per series through the labels.
+	Line
+	// Histogram draws the first series as adjacent bars, for buckets of a
+	// continuous range.
+	Histogram
+)
+
+// Colors are the colors of the series, in order.
+var Colors = []string{"#219dfe", "#fe8a21", "#2bb673", "#9b59b6", "#e74c3c", "#34495e", "#f1c40f", "#1abc9c"}
+
+const (
+	fontFamily = "Helvetica Neue, Helvetica, Arial, sans-serif"
+	fontSize   = 11
+	// charWidth is roughly how wide a character of a label is.
+	charWidth = 6.5
+	yTicks    = 5
+
+	maxBarWidth = 40
+)
+
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// Chart is a chart of one or more series over the same labels.
+type Chart struct {
+	Kind   Kind
+	Labels []string
+	Series []Series
+
+	Width, Height int
+
+	// Suffix is appended to the values, e.g. "%".
+	Suffix string
+}
+
+// New returns a chart of the given size.
+func New(kind Kind, width, height int, labels []string, series ...Series) *Chart {
+	return &Chart{Kind: kind, Labels: labels, Series: series, Width: width, Height: height}
+}
+
+// NiceNum finds a "nice" number approximately equal to x. Round the number if round is true, otherwise take the
+// ceiling.  Described on Graphics Gems pg. 63
+func NiceNum(x float64, round bool) float64 {
+	exp := int(math.Floor(math.Log10(x)))
+	f := x / math.Pow10(exp)
+
+	var nf int
+	if round {
+		if f < 1.5 {
+			nf = 1
+		} else if f < 3 {
+			nf = 2
+		} else if f < 7 {
+			nf = 5
+		} else {
+			nf = 10
+		}
+	} else {
+		if f <= 1 {
+			nf = 1
+		} else if f <= 2 {
+			nf = 2
+		} else if f <= 5 {
+			nf = 5
+		} else {
+			nf = 10
+		}
+	}
+
+	return float64(nf) * math.Pow10(exp)
+}
+
+// Ticks returns about n nicely spaced ticks covering min to max: the first
+// and last tick and the step between them. If integer is set the step is at
+// least 1.
+func Ticks(min, max float64, n int, integer bool) (lo, hi, step float64) {
+	if max <= min {
+		max = min + 1
+	}
+	r := NiceNum(max-min, false)
+	step = NiceNum(r/float64(n-1), true)
+	if integer && step < 1 {
+		step = 1
+	}
+	lo = math.Floor(min/step) * step
+	hi = math.Ceil(max/step) * step
+	return lo, hi, step
+}
+
+// SVG returns the chart as an inline SVG element.
+func (c *Chart) SVG() template.HTML {
+	var b bytes.Buffer
+	c.WriteSVG(&b)
+	return template.HTML(b.String())
+}
+
+// WriteSVG writes the chart as an SVG element.
+func (c *Chart) WriteSVG(w io.Writer) error {
+	p := &plot{Chart: c}
+	p.layout()
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" class="chart" width="%d" height="%d" viewBox="0 0 %d %d" font-family="%s" font-size="%d">`,
+		c.Width, c.Height, c.Width, c.Height, fontFamily, fontSize)
+	p.axes(b)
+	switch c.Kind {
+	case Line:
+		p.lines(b)
+	default:
+		p.bars(b)
+	}
+	p.legend(b)
+	b.WriteString(`</svg>`)
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// plot is a chart laid out on its canvas.
+type plot struct {
+	*Chart
+
+	left, top, width, height float64
+	lo, hi, step             float64
+	band                     float64
+}
+
+func (p *plot) layout() {
+	max := 0.0
+	integer := true
+	for i := range p.Labels {
+		sum := 0.0
+		for _, s := range p.series() {
+			v := value(s, i)
+			if p.Kind == StackedBar {
+				sum += v
+			} else if v > sum {
+				sum = v
+			}
+			integer = integer && v == math.Trunc(v)
+		}
+		if sum > max {
+			max = sum
+		}
+	}
+	p.lo, p.hi, p.step = Ticks(0, max, yTicks, integer)
+
+	// leave room for the widest tick label on the left, and the legend and
+	// the value labels of the highest bar on top
+	widest := 0
+	for v := p.lo; v <= p.hi+p.step/2; v += p.step {
+		if n := len(p.format(v)); n > widest {
+			widest = n
+		}
+	}
+	p.left = float64(widest)*charWidth + 8
+	p.top = 16
+	if len(p.series()) > 1 {
+		p.top += 18
+	}
+	p.width = float64(p.Width) - p.left - 8
+	p.height = float64(p.Height) - p.top - 22
+	if len(p.Labels) > 0 {
+		p.band = p.width / float64(len(p.Labels))
+	}
+}
+
+func (p *plot) series() []Series {
+	if p.Kind == Histogram && len(p.Series) > 1 {
+		return p.Series[:1]
+	}
+	return p.Series
+}
+
+func value(s Series, i int) float64 {
+	if i < len(s.Values) {
+		return s.Values[i]
+	}
+	return 0
+}
+
+func (p *plot) format(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + p.Suffix
+}
+
+func (p *plot) y(v float64) float64 {
+	return p.top + p.height - (v-p.lo)/(p.hi-p.lo)*p.height
+}
+
+func (p *plot) axes(b *bytes.Buffer) {
+	for v := p.lo; v <= p.hi+p.step/2; v += p.step {
+		y := p.y(v)
+		fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#e5e5e5"/>`, f(p.left), f(y), f(p.left+p.width), f(y))
+		fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="end" fill="#777">%s</text>`, f(p.left-4), f(y+4), esc(p.format(v)))
+	}
+	base := p.y(p.lo)
+	fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#999"/>`, f(p.left), f(base), f(p.left+p.width), f(base))
+
+	// skip labels so they do not overlap when there are many
+	widest := 0
+	for _, l := range p.Labels {
+		if len(l) > widest {
+			widest = len(l)
+		}
+	}
+	every := 1
+	if p.band > 0 {
+		every = int(math.Ceil((float64(widest)*charWidth + 6) / p.band))
+	}
+	for i, l := range p.Labels {
+		if i%every != 0 {
+			continue
+		}
+		x := p.left + (float64(i)+0.5)*p.band
+		fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle" fill="#333">%s</text>`, f(x), f(base+15), esc(l))
+	}
+}
+
+func (p *plot) bars(b *bytes.Buffer) {
+	series := p.series()
+	n := len(series)
+	if n == 0 {
+		return
+	}
+	pad := p.band * 0.15
+	if p.Kind == Histogram {
+		pad = 0.5
+	}
+	width := p.band - 2*pad
+	if p.Kind == Bar {
+		width /= float64(n)
+	}
+	if p.Kind != Histogram && width > maxBarWidth {
+		// center narrower bars in their band
+		width = maxBarWidth
+		group := width
+		if p.Kind == Bar {
+			group *= float64(n)
+		}
+		pad = (p.band - group) / 2
+	}
+	labels := n == 1 && p.Kind != StackedBar && float64(len(p.format(p.hi)))*charWidth <= p.band
+
+	for i, label := range p.Labels {
+		x := p.left + float64(i)*p.band + pad
+		stack := p.lo
+		for j, s := range series {
+			v := value(s, i)
+			if v <= 0 {
+				continue
+			}
+			bx, from := x, p.lo
+			switch p.Kind {
+			case Bar:
+				bx += float64(j) * width
+			case StackedBar:
+				from = stack
+				stack += v
+			}
+			top, bottom := p.y(from+v), p.y(from)
+			title := label + ": " + p.format(v)
+			if n > 1 {
+				title = s.Name + ", " + title
+			}
+			fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"><title>%s</title></rect>`,
+				f(bx), f(top), f(width), f(bottom-top), color(j), esc(title))
+			if labels {
+				fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle" fill="#333">%s</text>`, f(bx+width/2), f(top-3), esc(p.format(v)))
+			}
+		}
+	}
+}
+
+func (p *plot) lines(b *bytes.Buffer) {
+	for j, s := range p.series() {
+		points := make([]string, 0, len(p.Labels))
+		for i := range p.Labels {
+			points = append(points, f(p.left+(float64(i)+0.5)*p.band)+","+f(p.y(value(s, i))))
+		}
+		fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), color(j))
+		for i, label := range p.Labels {
+			v := value(s, i)
+			fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="3" fill="%s"><title>%s</title></circle>`,
+				f(p.left+(float64(i)+0.5)*p.band), f(p.y(v)), color(j), esc(s.Name+" "+label+": "+p.format(v)))
+		}
+	}
+}
+
+func (p *plot) legend(b *bytes.Buffer) {
+	series := p.series()
+	if len(series) < 2 {
+		return
+	}
+	x := p.left
+	for j, s := range series {
+		fmt.Fprintf(b, `<rect x="%s" y="4" width="10" height="10" fill="%s"/>`, f(x), color(j))
+		fmt.Fprintf(b, `<text x="%s" y="13" fill="#333">%s</text>`, f(x+14), esc(s.Name))
+		x += 14 + float64(len(s.Name))*charWidth + 12
+	}
+}
+
+func color(i int) string {
+	return Colors[i%len(Colors)]
+}
+
+func f(x float64) string {
+	return strconv.FormatFloat(math.Round(x*10)/10, 'f', -1, 64)
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
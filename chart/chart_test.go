@@ -0,0 +1,102 @@
+package chart
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestNiceNum(t *testing.T) {
+	for _, tt := range []struct {
+		x     float64
+		round bool
+		want  float64
+	}{
+		{0.3, false, 0.5},
+		{1, false, 1},
+		{1.2, false, 2},
+		{4, false, 5},
+		{6, false, 10},
+		{730, false, 1000},
+		{1.2, true, 1},
+		{2.9, true, 2},
+		{3, true, 5},
+		{68, true, 50},
+		{75, true, 100},
+	} {
+		if got := NiceNum(tt.x, tt.round); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("NiceNum(%v, %v) = %v, want %v", tt.x, tt.round, got, tt.want)
+		}
+	}
+}
+
+func TestTicks(t *testing.T) {
+	for _, tt := range []struct {
+		min, max     float64
+		integer      bool
+		lo, hi, step float64
+	}{
+		{0, 100, false, 0, 100, 20},
+		{0, 47, false, 0, 50, 10},
+		{0, 3, true, 0, 3, 1},
+		{0, 0.3, false, 0, 0.3, 0.1},
+		{0, 0.3, true, 0, 1, 1},
+		{0, 0, true, 0, 1, 1},
+		{5, 5, false, 5, 6, 0.2},
+		{-12, 38, false, -20, 40, 10},
+	} {
+		lo, hi, step := Ticks(tt.min, tt.max, yTicks, tt.integer)
+		if math.Abs(lo-tt.lo) > 1e-9 || math.Abs(hi-tt.hi) > 1e-9 || math.Abs(step-tt.step) > 1e-9 {
+			t.Errorf("Ticks(%v, %v, %v) = %v, %v, %v, want %v, %v, %v",
+				tt.min, tt.max, tt.integer, lo, hi, step, tt.lo, tt.hi, tt.step)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	labels := []string{"Seed", "A", "B", "C"}
+	two := []Series{{"2012", []float64{4, 2, 1, 0}}, {"2013", []float64{6, 3, 0, 1}}}
+	for _, tt := range []struct {
+		name  string
+		chart *Chart
+	}{
+		{"bar", New(Bar, 400, 200, labels, two[0])},
+		{"bar_series", New(Bar, 400, 200, labels, two...)},
+		{"stacked_bar", New(StackedBar, 400, 200, labels, two...)},
+		{"line", New(Line, 400, 200, labels, two...)},
+		{"histogram", &Chart{Kind: Histogram, Labels: labels, Series: two, Width: 400, Height: 200, Suffix: "%"}},
+		{"empty", New(Bar, 400, 200, nil)},
+		{"empty_series", New(Line, 400, 200, labels, Series{Name: "none"})},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := tt.chart.WriteSVG(&b); err != nil {
+				t.Fatal(err)
+			}
+			got := b.Bytes()
+			if s := string(got); strings.Contains(s, "NaN") || strings.Contains(s, "Inf") {
+				t.Errorf("SVG has an invalid coordinate: %s", s)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".svg")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("SVG differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
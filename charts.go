@@ -0,0 +1,104 @@
+package fundhawk
+
+import (
+	"html/template"
+	"sort"
+	"strconv"
+
+	"github.com/jedchristiansen/fundhawk/chart"
+)
+
+// Sizes of the charts on the firm and comparison pages.
+const (
+	chartWidth   = 460
+	chartHeight  = 180
+	compareWidth = 720
+)
+
+func bucketSeries(b BucketedInts) ([]string, chart.Series) {
+	labels := make([]string, len(b.Buckets))
+	s := chart.Series{Values: make([]float64, len(b.Buckets))}
+	for i, x := range b.Buckets {
+		labels[i], s.Values[i] = x.Name, float64(x.Count)
+	}
+	return labels, s
+}
+
+func bucketChart(kind chart.Kind, b BucketedInts, suffix []string) template.HTML {
+	labels, s := bucketSeries(b)
+	c := chart.New(kind, chartWidth, chartHeight, labels, s)
+	if len(suffix) > 0 {
+		c.Suffix = suffix[0]
+	}
+	return c.SVG()
+}
+
+// BarChart renders a distribution as an SVG bar chart, with an optional
+// suffix for the values such as "%".
+func BarChart(b BucketedInts, suffix ...string) template.HTML {
+	return bucketChart(chart.Bar, b, suffix)
+}
+
+// HistogramChart renders a distribution over value ranges as an SVG
+// histogram.
+func HistogramChart(b BucketedInts, suffix ...string) template.HTML {
+	return bucketChart(chart.Histogram, b, suffix)
+}
+
+// YearChart renders counts by year as an SVG bar chart, with a bar for every
+// year from the first to the last so gaps show.
+func YearChart(byYear map[int]int64) template.HTML {
+	years := make([]int, 0, len(byYear))
+	for y := range byYear {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+
+	var b BucketedInts
+	if len(years) > 0 {
+		for y := years[0]; y <= years[len(years)-1]; y++ {
+			b.Buckets = append(b.Buckets, BucketedInt{strconv.Itoa(y), byYear[y]})
+		}
+	}
+	return BarChart(b)
+}
+
+// SectorShiftChart renders a firm's sector mix by year as an SVG stacked bar
+// chart of percentages.
+func SectorShiftChart(s SectorShift) template.HTML {
+	labels := make([]string, len(s.Years))
+	series := make([]chart.Series, len(s.Sectors))
+	for j, name := range s.Sectors {
+		series[j] = chart.Series{Name: name, Values: make([]float64, len(s.Years))}
+	}
+	for i, y := range s.Years {
+		labels[i] = strconv.Itoa(y.Year)
+		for j, share := range y.Shares {
+			series[j].Values[i] = float64(share)
+		}
+	}
+	c := chart.New(chart.StackedBar, chartWidth, chartHeight+40, labels, series...)
+	c.Suffix = "%"
+	return c.SVG()
+}
+
+// ComparedChart renders a distribution of several firms as an SVG chart of
+// the given kind ("bar" or "line") with a series per firm.
+func ComparedChart(kind string, d ComparedDist, firms []FirmData) template.HTML {
+	labels := make([]string, len(d.Rows))
+	series := make([]chart.Series, len(firms))
+	for j, f := range firms {
+		series[j] = chart.Series{Name: f.Name, Values: make([]float64, len(d.Rows))}
+	}
+	for i, r := range d.Rows {
+		labels[i] = r.Name
+		for j, n := range r.Counts {
+			series[j].Values[i] = float64(n)
+		}
+	}
+	k := chart.Bar
+	if kind == "line" {
+		k = chart.Line
+	}
+	return chart.New(k, compareWidth, chartHeight+20, labels, series...).SVG()
+}
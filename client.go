@@ -0,0 +1,239 @@
+package fundhawk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Client is an HTTP client shared by all fetch workers. It limits the request
+// rate across workers and retries throttled, failed and timed out requests
+// with exponential backoff.
+type Client struct {
+	HTTP    *http.Client
+	Limiter *RateLimiter
+	Retries int
+
+	// Backoff is the delay before the first retry. It doubles with every
+	// attempt up to MaxBackoff, and the actual delay is a random duration up
+	// to that bound.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// MaxRetryAfter caps the delay a server's Retry-After header can ask
+	// for, so one bad header cannot hold the workers back indefinitely.
+	MaxRetryAfter time.Duration
+}
+
+// NewClient returns a client making at most rate requests per second (no
+// limit if rate is 0), each retried up to retries times and given up after
+// timeout (no timeout if 0).
+func NewClient(rate float64, retries int, timeout time.Duration) *Client {
+	return &Client{
+		HTTP:          &http.Client{Timeout: timeout},
+		Limiter:       NewRateLimiter(rate, 1),
+		Retries:       retries,
+		Backoff:       500 * time.Millisecond,
+		MaxBackoff:    time.Minute,
+		MaxRetryAfter: 5 * time.Minute,
+	}
+}
+
+// Get fetches uri like Do.
+func (c *Client) Get(uri string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends a request without a body, retrying on timeouts, dropped
+// connections, 429 and 5xx responses. Other errors, such as a failed DNS
+// lookup or a refused connection, are returned at once. A Retry-After header
+// from the server overrides the backoff and holds back every worker sharing
+// the client's rate limiter.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	uri := req.URL.String()
+	for attempt := 0; ; attempt++ {
+		c.Limiter.Wait()
+		res, err := c.HTTP.Do(req)
+		if err == nil && !retryable(res.StatusCode) {
+			return res, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			wait = retryAfter(res.Header.Get("Retry-After"))
+			if c.MaxRetryAfter > 0 && wait > c.MaxRetryAfter {
+				wait = c.MaxRetryAfter
+			}
+			res.Body.Close()
+			err = fmt.Errorf("get %s - incorrect response code received - %d", uri, res.StatusCode)
+		} else if !retryableError(err) {
+			return nil, err
+		}
+		if attempt >= c.Retries {
+			return nil, err
+		}
+
+		if wait > 0 {
+			c.Limiter.Pause(wait)
+		} else {
+			wait = c.backoff(attempt)
+		}
+		time.Sleep(wait)
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := float64(c.Backoff) * math.Pow(2, float64(attempt))
+	if d > float64(c.MaxBackoff) {
+		d = float64(c.MaxBackoff)
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryableError reports whether a request that failed with err may succeed
+// if sent again: it timed out, the server dropped the connection, or a DNS
+// lookup failed for a reason that may go away.
+func retryableError(err error) bool {
+	var dns *net.DNSError
+	if errors.As(err, &dns) {
+		return dns.IsTimeout || dns.IsTemporary
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryAfter parses a Retry-After header, which is either a number of seconds
+// or an HTTP date.
+func retryAfter(h string) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(h); err == nil {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// RateLimiter is a token bucket that allows rate requests per second with
+// bursts of up to burst requests. A nil RateLimiter never waits.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	until  time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a request may be made.
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if now.Before(l.until) {
+			d := l.until.Sub(now)
+			l.mu.Unlock()
+			time.Sleep(d)
+			continue
+		}
+
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+		d := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+		time.Sleep(d)
+	}
+}
+
+// Pause holds back all requests for d.
+func (l *RateLimiter) Pause(d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if t := time.Now().Add(d); t.After(l.until) {
+		l.until = t
+	}
+	l.mu.Unlock()
+}
+
+// FailureLog collects the firms and companies that could not be loaded, so
+// they can be reported once fetching is done instead of disappearing from the
+// site.
+type FailureLog struct {
+	mu     sync.Mutex
+	errors map[string]error
+}
+
+func (f *FailureLog) Add(permalink string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errors == nil {
+		f.errors = make(map[string]error)
+	}
+	f.errors[permalink] = err
+}
+
+func (f *FailureLog) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.errors)
+}
+
+// Report writes every failed permalink with its last error to w.
+func (f *FailureLog) Report(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.errors) == 0 {
+		return
+	}
+
+	links := make([]string, 0, len(f.errors))
+	for p := range f.errors {
+		links = append(links, p)
+	}
+	sort.Strings(links)
+
+	fmt.Fprintf(w, "\n%d failed to load:\n", len(links))
+	for _, p := range links {
+		fmt.Fprintf(w, "  %s - %s\n", p, f.errors[p])
+	}
+}
@@ -0,0 +1,124 @@
+package fundhawk
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClient returns a client retrying quickly, with a rate limiter to be
+// held back by Retry-After.
+func testClient(retries int) *Client {
+	c := NewClient(1000, retries, time.Second)
+	c.Backoff, c.MaxBackoff = time.Millisecond, 10*time.Millisecond
+	return c
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+	}))
+	defer s.Close()
+
+	c := testClient(3)
+	c.MaxRetryAfter = 50 * time.Millisecond
+	start := time.Now()
+	res, err := c.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+	// an hour is capped at MaxRetryAfter
+	if d := time.Since(start); d < c.MaxRetryAfter || d > 5*time.Second {
+		t.Errorf("waited %v, want about %v", d, c.MaxRetryAfter)
+	}
+}
+
+func TestClientServerErrors(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	if _, err := testClient(2).Get(s.URL); err == nil {
+		t.Error("got no error after every attempt failed")
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	res, err := testClient(2).Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("got %d after %d requests, want a single 404", res.StatusCode, requests)
+	}
+}
+
+func TestClientDNSError(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		err   *net.DNSError
+		dials int32
+	}{
+		{"no such host", &net.DNSError{Err: "no such host", Name: "api.example", IsNotFound: true}, 1},
+		{"timeout", &net.DNSError{Err: "i/o timeout", Name: "api.example", IsTimeout: true}, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var dials int32
+			c := testClient(2)
+			c.HTTP.Transport = &http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					atomic.AddInt32(&dials, 1)
+					return nil, &net.OpError{Op: "dial", Net: network, Err: tt.err}
+				},
+			}
+			if _, err := c.Get("http://api.example/"); err == nil {
+				t.Error("got no error")
+			}
+			if n := atomic.LoadInt32(&dials); n != tt.dials {
+				t.Errorf("got %d attempts, want %d", n, tt.dials)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		l.Wait()
+	}
+	// the first request is free, the next ten take 10ms each
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("11 requests at 100/s took %v", d)
+	}
+	if NewRateLimiter(0, 1) != nil {
+		t.Error("got a limiter for no limit")
+	}
+}
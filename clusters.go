@@ -0,0 +1,219 @@
+package fundhawk
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Cluster is a syndicate: a community of firms that coinvest with each other
+// more than with the rest of the network.
+type Cluster struct {
+	// ID is the permalink of the member that comes first alphabetically, so
+	// a cluster keeps its page as long as that firm stays in it.
+	ID   string
+	Name string
+
+	// Members are sorted by PageRank, most central first.
+	Members []*VC
+
+	// Companies are the portfolio companies backed by more than one member
+	// within the ClusterFrom to ClusterTo window.
+	Companies []ClusterCompany
+}
+
+// ClusterCompany is a company shared by the members of a cluster.
+type ClusterCompany struct {
+	Company Company
+	VCs     []*VC
+}
+
+type clusterCompanies []ClusterCompany
+
+func (c clusterCompanies) Len() int      { return len(c) }
+func (c clusterCompanies) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c clusterCompanies) Less(i, j int) bool {
+	if len(c[i].VCs) != len(c[j].VCs) {
+		return len(c[i].VCs) > len(c[j].VCs)
+	}
+	return c[i].Company.Name < c[j].Company.Name
+}
+
+type clustersBySize []*Cluster
+
+func (c clustersBySize) Len() int      { return len(c) }
+func (c clustersBySize) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c clustersBySize) Less(i, j int) bool {
+	if len(c[i].Members) != len(c[j].Members) {
+		return len(c[i].Members) > len(c[j].Members)
+	}
+	return c[i].ID < c[j].ID
+}
+
+const labelIterations = 100
+
+// inClusterYears reports whether a round falls in the ClusterFrom to
+// ClusterTo window.
+func (d *Dataset) inClusterYears(r Round) bool {
+	from, to := d.ClusterFrom, d.ClusterTo
+	if from == 0 {
+		from = d.MinYear
+	}
+	if to == 0 {
+		to = d.MaxYear
+	}
+	y := roundYear(r)
+	return y >= from && y <= to
+}
+
+// coinvestments counts the rounds every two firms took part in together,
+// keeping only rounds from the ClusterFrom to ClusterTo window.
+func (d *Dataset) coinvestments() map[*VC]map[*VC]int {
+	weights := make(map[*VC]map[*VC]int)
+	for rid, vcs := range d.RoundVCs {
+		if !d.inClusterYears(d.Rounds[rid]) {
+			continue
+		}
+		for a := range vcs {
+			for b := range vcs {
+				if a == b {
+					continue
+				}
+				if weights[a] == nil {
+					weights[a] = make(map[*VC]int)
+				}
+				weights[a][b]++
+			}
+		}
+	}
+	return weights
+}
+
+// labelPropagation finds communities by repeatedly giving every firm the
+// label carrying the most coinvestments among its neighbours, until no label
+// changes. Firms are visited in a shuffled but fixed order, so the result is
+// the same on every run, and ties keep the current label or else go to the
+// smallest one.
+func labelPropagation(vcs []*VC, weights map[*VC]map[*VC]int) map[*VC]int {
+	labels := make(map[*VC]int, len(vcs))
+	for i, vc := range vcs {
+		labels[vc] = i
+	}
+
+	order := append(make([]*VC, 0, len(vcs)), vcs...)
+	rnd := rand.New(rand.NewSource(1))
+	for iter := 0; iter < labelIterations; iter++ {
+		rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		changed := false
+		for _, vc := range order {
+			if len(weights[vc]) == 0 {
+				continue
+			}
+			score := make(map[int]int)
+			for v, w := range weights[vc] {
+				score[labels[v]] += w
+			}
+			max := 0
+			for _, s := range score {
+				if s > max {
+					max = s
+				}
+			}
+			best := labels[vc]
+			if score[best] < max {
+				best = len(vcs)
+				for l, s := range score {
+					if s == max && l < best {
+						best = l
+					}
+				}
+			}
+			if best != labels[vc] {
+				labels[vc] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return labels
+}
+
+// calculateClusters groups the firms into syndicates with label propagation
+// over the coinvestment network. Firms without a coinvestor in the window
+// are not in any cluster. It needs the centrality to order members.
+func (d *Dataset) calculateClusters() {
+	vcs := make([]*VC, 0, len(d.VCs))
+	for _, vc := range d.VCs {
+		vc.Cluster = nil
+		vcs = append(vcs, vc)
+	}
+	sort.Sort(vcsByPermalink(vcs))
+
+	labels := labelPropagation(vcs, d.coinvestments())
+	byLabel := make(map[int]*Cluster)
+	for _, vc := range vcs {
+		c, ok := byLabel[labels[vc]]
+		if !ok {
+			c = &Cluster{}
+			byLabel[labels[vc]] = c
+		}
+		c.Members = append(c.Members, vc)
+	}
+
+	d.Clusters = make([]*Cluster, 0)
+	for _, c := range byLabel {
+		if len(c.Members) < 2 {
+			continue
+		}
+		// members were added in permalink order
+		c.ID = c.Members[0].Permalink
+		sort.Stable(byPageRank(c.Members))
+		c.Name = c.Members[0].Name
+		d.Clusters = append(d.Clusters, c)
+	}
+	sort.Sort(clustersBySize(d.Clusters))
+
+	shared := make(map[*Cluster]map[Company]map[*VC]bool)
+	for _, c := range d.Clusters {
+		shared[c] = make(map[Company]map[*VC]bool)
+		for _, vc := range c.Members {
+			vc.Cluster = c
+		}
+	}
+	for rid, vcs := range d.RoundVCs {
+		r := d.Rounds[rid]
+		if !d.inClusterYears(r) {
+			continue
+		}
+		for vc := range vcs {
+			if vc.Cluster == nil {
+				continue
+			}
+			backers := shared[vc.Cluster][r.Company]
+			if backers == nil {
+				backers = make(map[*VC]bool)
+				shared[vc.Cluster][r.Company] = backers
+			}
+			backers[vc] = true
+		}
+	}
+
+	for _, c := range d.Clusters {
+		c.Companies = make([]ClusterCompany, 0)
+		for company, backers := range shared[c] {
+			if len(backers) < 2 {
+				continue
+			}
+			cc := ClusterCompany{Company: company}
+			for _, vc := range c.Members {
+				if backers[vc] {
+					cc.VCs = append(cc.VCs, vc)
+				}
+			}
+			c.Companies = append(c.Companies, cc)
+		}
+		sort.Sort(clusterCompanies(c.Companies))
+	}
+}
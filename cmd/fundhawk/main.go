@@ -0,0 +1,195 @@
+// Command fundhawk loads CrunchBase data and generates the Fundhawk site.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jedchristiansen/fundhawk"
+	"github.com/ncw/swift"
+)
+
+var apiKey = flag.String("key", "", "CrunchBase API key")
+var remoteMode = flag.Bool("remote", false, "Fetch from CrunchBase API instead of local filesystem (same as -source=crunchbase)")
+var sourceName = flag.String("source", "file", "Data source to load from ("+strings.Join(fundhawk.Sources(), ", ")+")")
+var dataPath = flag.String("path", "./data", "Path to local data on the filesystem")
+var concurrency = flag.Int("workers", 40, "Number of workers to fetch with")
+var upload = flag.Bool("upload", false, "Upload the generated site to Rackspace")
+var firms = flag.String("firms", "", "List of firms, one per line")
+var save = flag.Bool("save", false, "Save downloaded data")
+var refresh = flag.Bool("refresh", false, "Only bring the local data mirror up to date, skipping unchanged firms")
+var companies = flag.Bool("companies", false, "Also load the profile of every portfolio company, which is on by default for the file and csv sources (one more API request per company with -source=crunchbase)")
+var minYear = flag.Int("minyear", fundhawk.DefaultMinYear, "First year of rounds to count in per-year metrics")
+var maxYear = flag.Int("maxyear", time.Now().Year(), "Last year of rounds to count, taken to be under way")
+var clusterFrom = flag.Int("clusterfrom", 0, "Only cluster firms by coinvestments from this year on")
+var clusterTo = flag.Int("clusterto", 0, "Only cluster firms by coinvestments up to this year")
+var peers = flag.String("peers", fundhawk.PeersAll, "Rank each firm's metrics among all firms (all) or firms of similar check size (size) or stage focus (stage)")
+var compare = flag.String("compare", "", "Only write a report comparing these firms, e.g. a,b")
+var export = flag.String("export", "", "Only write every firm's metrics to this path as .csv and .xlsx")
+var verify = flag.Bool("verify", false, "Check the local data mirror and quarantine firm files that do not parse")
+
+var requestRate = flag.Float64("rps", 8, "Maximum CrunchBase API requests per second (0 for no limit)")
+var maxRetries = flag.Int("retries", 6, "Number of times to retry a failed CrunchBase request")
+var requestTimeout = flag.Duration("timeout", 30*time.Second, "Timeout for a single CrunchBase request")
+
+var rsUsername = flag.String("rsuser", "", "Rackspace username")
+var rsApiKey = flag.String("rskey", "", "Rackspace API key")
+var rsBucket = flag.String("bucket", "", "Rackspace Cloud Files bucket")
+var rsAssetUrl = flag.String("asseturl", "", "Asset URL")
+
+func MaybePanic(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func readFirms(path string) fundhawk.Permalinks {
+	f, err := os.Open(path)
+	MaybePanic(err)
+	defer f.Close()
+
+	var list fundhawk.Permalinks
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if scanner.Text() != "" {
+			list = append(list, fundhawk.Permalink{Link: scanner.Text()})
+		}
+	}
+	MaybePanic(scanner.Err())
+	return list
+}
+
+// loadCompanies reports whether to load the company profiles: if -companies
+// says so, or by default unless every profile is another API request.
+func loadCompanies() bool {
+	load := *sourceName != "crunchbase"
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "companies" {
+			load = *companies
+		}
+	})
+	return load
+}
+
+func exportFirms(d *fundhawk.Dataset, path string) {
+	header, rows := d.Export()
+	for ext, write := range map[string]func(io.Writer, []string, [][]string) error{
+		".csv":  fundhawk.WriteCSV,
+		".xlsx": fundhawk.WriteXLSX,
+	} {
+		f, err := os.Create(path + ext)
+		MaybePanic(err)
+		MaybePanic(write(f, header, rows))
+		MaybePanic(f.Close())
+		fmt.Printf("\nwrote %d firms to %s%s", len(rows), path, ext)
+	}
+	fmt.Println()
+}
+
+func newSite(progress *fundhawk.Progress) *fundhawk.Site {
+	var site *fundhawk.Site
+	if *upload {
+		rs := &swift.Connection{UserName: *rsUsername, ApiKey: *rsApiKey, AuthUrl: "https://identity.api.rackspacecloud.com/v1.0"}
+		MaybePanic(rs.Authenticate())
+		site = fundhawk.NewSite(
+			&fundhawk.CloudFiles{Conn: rs, Container: *rsBucket, CacheControl: "public, max-age=300"},
+			&fundhawk.CloudFiles{Conn: rs, Container: *rsBucket + "-assets", CacheControl: "public, max-age=31556925"},
+			*rsAssetUrl,
+		)
+	} else {
+		site = fundhawk.NewSite(fundhawk.DirOutput("output"), fundhawk.DirOutput("output/assets"), "/assets")
+	}
+	site.Workers = *concurrency
+	site.Progress = progress
+	return site
+}
+
+func main() {
+	flag.Parse()
+	runtime.GOMAXPROCS(runtime.NumCPU())
+	switch *peers {
+	case fundhawk.PeersAll, fundhawk.PeersSize, fundhawk.PeersStage:
+	default:
+		MaybePanic(fmt.Errorf("unknown peer group %q", *peers))
+	}
+
+	if *remoteMode {
+		*sourceName = "crunchbase"
+	}
+	if *verify {
+		_, err := fundhawk.VerifyMirror(*dataPath, os.Stdout)
+		MaybePanic(err)
+		return
+	}
+	if *refresh {
+		*sourceName = "crunchbase"
+		*save = true
+	}
+
+	progress := &fundhawk.Progress{Out: os.Stdout}
+	source, err := fundhawk.NewSource(*sourceName, fundhawk.SourceConfig{
+		Path:          *dataPath,
+		Key:           *apiKey,
+		Client:        fundhawk.NewClient(*requestRate, *maxRetries, *requestTimeout),
+		Progress:      progress,
+		Save:          *save,
+		SkipUnchanged: *refresh,
+	})
+	MaybePanic(err)
+
+	var list fundhawk.Permalinks
+	if *firms != "" {
+		list = readFirms(*firms)
+	} else {
+		// only the API's enumeration is slow enough to be worth resuming, and
+		// the local sources may read from a mirror that is not writable
+		var cursor string
+		if _, remote := source.(*fundhawk.HTTPSource); remote {
+			cursor = *dataPath + "/financial-organizations.cursor"
+		}
+		list, err = fundhawk.FirmList(source, cursor, os.Stdout)
+		MaybePanic(err)
+	}
+
+	progress.Reset(len(list))
+	loader := fundhawk.NewLoader(source, *concurrency)
+	loader.MinYear, loader.MaxYear = *minYear, *maxYear
+	loader.Log = os.Stdout
+	d := loader.Load(list)
+	failed := loader.Failures.Len()
+	if loadCompanies() {
+		list := d.CompanyPermalinks()
+		fmt.Printf("\nloading %d companies\n", len(list))
+		progress.Reset(len(list))
+		loader.LoadCompanies(d)
+	}
+	loader.Failures.Report(os.Stdout)
+
+	if *refresh {
+		fmt.Printf("\n%d firms updated, %d unchanged\n", len(list)-loader.Unchanged()-failed, loader.Unchanged())
+		return
+	}
+
+	d.ClusterFrom, d.ClusterTo = *clusterFrom, *clusterTo
+	d.Peers = *peers
+	d.Calculate()
+
+	if *export != "" {
+		exportFirms(d, *export)
+		return
+	}
+	if *compare != "" {
+		path, err := newSite(progress).RenderComparison(d, strings.Split(*compare, ","))
+		MaybePanic(err)
+		fmt.Printf("\nwrote %s.html and %s.json\n", path, path)
+		return
+	}
+
+	MaybePanic(newSite(progress).Render(d))
+}
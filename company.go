@@ -0,0 +1,164 @@
+package fundhawk
+
+import (
+	"sort"
+	"strings"
+)
+
+// CompanyHistory is everything the dataset knows about a portfolio company:
+// each of its rounds with the firms that took part.
+type CompanyHistory struct {
+	Company Company
+	Rounds  []CompanyRound
+	Raised  int64
+
+	// Profile is nil unless the company itself was loaded.
+	Profile *CompanyProfile
+}
+
+// Exit returns the company's exit, or nil if it has not exited.
+func (c *CompanyHistory) Exit() *Exit {
+	return c.Profile.Exit()
+}
+
+// CompanyRound is one funding round of a company.
+type CompanyRound struct {
+	ID    string
+	Round Round
+	VCs   []*VC
+
+	// Lead is the firm that led the round. LeadInferred is set if the source
+	// did not say and Lead was picked by calculateLeads.
+	Lead         *VC
+	LeadInferred bool
+}
+
+// Amount is the round size, or 0 if it was not disclosed.
+func (r CompanyRound) Amount() int64 {
+	if r.Round.Amount == nil || *r.Round.Amount < 1 {
+		return 0
+	}
+	return RoundInt(*r.Round.Amount)
+}
+
+// Has reports whether vc took part in the round.
+func (r CompanyRound) Has(vc *VC) bool {
+	for _, v := range r.VCs {
+		if v == vc {
+			return true
+		}
+	}
+	return false
+}
+
+// companyRounds sorts a company's rounds by date. Undated rounds could have
+// happened at any point, so they go last, where they are not taken for the
+// round before a dated one.
+type companyRounds []CompanyRound
+
+func (c companyRounds) Len() int      { return len(c) }
+func (c companyRounds) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c companyRounds) Less(i, j int) bool {
+	ri, rj := c[i].Round, c[j].Round
+	if (ri.Year == nil) != (rj.Year == nil) {
+		return rj.Year == nil
+	}
+	if yi, yj := roundYear(ri), roundYear(rj); yi != yj {
+		return yi < yj
+	}
+	if !ri.Date.Equal(rj.Date) {
+		return ri.Date.Before(rj.Date)
+	}
+	return roundOrder(ri.Code) < roundOrder(rj.Code)
+}
+
+func roundYear(r Round) int {
+	if r.Year == nil {
+		return 0
+	}
+	return *r.Year
+}
+
+// roundOrder is the position of a round code in RoundCodeBuckets. Codes not
+// listed there sort after the lettered series.
+func roundOrder(code string) int {
+	for i, b := range RoundCodeBuckets {
+		if strings.ToLower(b) == code {
+			return i
+		}
+	}
+	return len(RoundCodeBuckets)
+}
+
+// RoundName is the display name of a round code, e.g. "A" for "a".
+func RoundName(code string) string {
+	for _, b := range RoundCodeBuckets {
+		if strings.ToLower(b) == code {
+			return b
+		}
+	}
+	return strings.Title(strings.Replace(code, "_", " ", -1))
+}
+
+type vcsByName []*VC
+
+func (v vcsByName) Len() int           { return len(v) }
+func (v vcsByName) Less(i, j int) bool { return v[i].Name < v[j].Name }
+func (v vcsByName) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+// calculateCompanies builds the company histories from the indexed rounds,
+// each firm's CompanyList and the company entries of the search index. Each
+// company is only added to the search index once, however often it runs.
+func (d *Dataset) calculateCompanies() {
+	d.Companies = make(map[string]*CompanyHistory)
+
+	for rid, vcs := range d.RoundVCs {
+		r := d.Rounds[rid]
+		c, ok := d.Companies[r.Company.Permalink]
+		if !ok {
+			c = &CompanyHistory{Company: r.Company, Profile: d.Profiles[r.Company.Permalink]}
+			d.Companies[r.Company.Permalink] = c
+		}
+
+		cr := CompanyRound{ID: rid, Round: r, VCs: make([]*VC, 0, len(vcs))}
+		for vc := range vcs {
+			cr.VCs = append(cr.VCs, vc)
+		}
+		sort.Sort(vcsByName(cr.VCs))
+		c.Rounds = append(c.Rounds, cr)
+		c.Raised += cr.Amount()
+	}
+
+	for _, c := range d.Companies {
+		sort.Sort(companyRounds(c.Rounds))
+
+		if d.indexed[c.Company.Permalink] {
+			continue
+		}
+		d.indexed[c.Company.Permalink] = true
+		d.DataList = append(d.DataList, []string{c.Company.Permalink, c.Company.Name, "companies"})
+		for prefix := range wordPrefixes(c.Company.Name) {
+			d.NamePrefixes[prefix] = append(d.NamePrefixes[prefix], len(d.DataList)-1)
+		}
+	}
+
+	for _, vc := range d.VCs {
+		vc.CompanyList = make(CompanyList, 0, len(vc.RoundsByCompany))
+		for company, rounds := range vc.RoundsByCompany {
+			c := d.Companies[company.Permalink]
+			inv := CompanyInvestment{Company: company, Rounds: int(rounds)}
+			for _, r := range c.Rounds {
+				if !r.Has(vc) {
+					continue
+				}
+				inv.Raised += r.Amount()
+				inv.RaisedShare += r.Amount() / int64(len(r.VCs))
+			}
+			if c.Raised > 0 {
+				inv.SharePercentage = int(inv.RaisedShare * 100 / c.Raised)
+			}
+			vc.CompanyList = append(vc.CompanyList, inv)
+		}
+		sort.Sort(vc.CompanyList)
+	}
+}
@@ -0,0 +1,210 @@
+package fundhawk
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// FirmData is the part of a firm that comparisons are made from.
+type FirmData struct {
+	Permalink    string        `json:"permalink"`
+	Name         string        `json:"name"`
+	RoundsByYear map[int]int64 `json:"rounds_by_year"`
+	SeriesDist   BucketedInts  `json:"series_dist"`
+	RaiseDist    BucketedInts  `json:"raise_dist"`
+	ShareDist    BucketedInts  `json:"share_dist"`
+	Companies    []NamedCount  `json:"companies"`
+	Coinvestors  []NamedCount  `json:"coinvestors"`
+}
+
+// NamedCount is a company or firm with the number of rounds it was in.
+type NamedCount struct {
+	Permalink string `json:"permalink"`
+	Name      string `json:"name"`
+	Rounds    int    `json:"rounds"`
+}
+
+// Data returns the firm's FirmData.
+func (vc *VC) Data() FirmData {
+	f := FirmData{
+		Permalink:    vc.Permalink,
+		Name:         vc.Name,
+		RoundsByYear: vc.RoundsByYear,
+		SeriesDist:   vc.SeriesDist,
+		RaiseDist:    vc.RaiseDist,
+		ShareDist:    vc.ShareDist,
+		Companies:    make([]NamedCount, 0, len(vc.CompanyList)),
+		Coinvestors:  make([]NamedCount, 0, len(vc.Partners)),
+	}
+	for _, c := range vc.CompanyList {
+		f.Companies = append(f.Companies, NamedCount{c.Company.Permalink, c.Company.Name, c.Rounds})
+	}
+	for v, p := range vc.Partners {
+		f.Coinvestors = append(f.Coinvestors, NamedCount{v.Permalink, v.Name, p.Rounds})
+	}
+	sort.Sort(namedCounts(f.Coinvestors))
+	return f
+}
+
+type namedCounts []NamedCount
+
+func (n namedCounts) Len() int      { return len(n) }
+func (n namedCounts) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+func (n namedCounts) Less(i, j int) bool {
+	if n[i].Rounds != n[j].Rounds {
+		return n[i].Rounds > n[j].Rounds
+	}
+	return n[i].Permalink < n[j].Permalink
+}
+
+// Comparison sets a few firms side by side. Every count list has an entry
+// per firm, in the order of Firms.
+type Comparison struct {
+	Firms             []FirmData     `json:"firms"`
+	RoundsByYear      ComparedDist   `json:"rounds_by_year"`
+	SeriesDist        ComparedDist   `json:"series_dist"`
+	RaiseDist         ComparedDist   `json:"raise_dist"`
+	ShareDist         ComparedDist   `json:"share_dist"`
+	SharedCompanies   []SharedCounts `json:"shared_companies"`
+	SharedCoinvestors []SharedCounts `json:"shared_coinvestors"`
+}
+
+// ComparedDist is a distribution of each compared firm, bucket by bucket.
+type ComparedDist struct {
+	Max  int64         `json:"max"`
+	Rows []ComparedRow `json:"rows"`
+}
+
+type ComparedRow struct {
+	Name   string  `json:"name"`
+	Counts []int64 `json:"counts"`
+}
+
+// SharedCounts is a company or coinvestor that more than one of the compared
+// firms has, with the number of rounds of each firm.
+type SharedCounts struct {
+	Permalink string `json:"permalink"`
+	Name      string `json:"name"`
+	Rounds    []int  `json:"rounds"`
+}
+
+type sharedCounts []SharedCounts
+
+func (s sharedCounts) Len() int      { return len(s) }
+func (s sharedCounts) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sharedCounts) Less(i, j int) bool {
+	ni, nj := s[i].firms(), s[j].firms()
+	if ni != nj {
+		return ni > nj
+	}
+	return s[i].Name < s[j].Name
+}
+
+func (s SharedCounts) firms() (n int) {
+	for _, r := range s.Rounds {
+		if r > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Compare returns the comparison of the named firms.
+func (d *Dataset) Compare(permalinks []string) (*Comparison, error) {
+	firms := make([]FirmData, 0, len(permalinks))
+	for _, p := range permalinks {
+		vc, ok := d.VCs[p]
+		if !ok {
+			return nil, fmt.Errorf("compare: no firm %q", p)
+		}
+		firms = append(firms, vc.Data())
+	}
+	return Compare(firms, d.MinYear, d.MaxYear), nil
+}
+
+// Compare puts firms side by side, with their rounds by year from minYear to
+// maxYear.
+func Compare(firms []FirmData, minYear, maxYear int) *Comparison {
+	c := &Comparison{Firms: firms}
+
+	years := make([]string, 0, maxYear-minYear+1)
+	byYear := make([]BucketedInts, len(firms))
+	for y := minYear; y <= maxYear; y++ {
+		years = append(years, strconv.Itoa(y))
+		for i, f := range firms {
+			byYear[i].Buckets = append(byYear[i].Buckets, BucketedInt{strconv.Itoa(y), f.RoundsByYear[y]})
+		}
+	}
+	c.RoundsByYear = compareDists(years, byYear)
+
+	dists := func(get func(FirmData) BucketedInts) []BucketedInts {
+		d := make([]BucketedInts, len(firms))
+		for i, f := range firms {
+			d[i] = get(f)
+		}
+		return d
+	}
+	c.SeriesDist = compareDists(RoundCodeBuckets, dists(func(f FirmData) BucketedInts { return f.SeriesDist }))
+	c.RaiseDist = compareDists(RoundSizeBuckets.Names(), dists(func(f FirmData) BucketedInts { return f.RaiseDist }))
+	c.ShareDist = compareDists(RoundShareBuckets.Names(), dists(func(f FirmData) BucketedInts { return f.ShareDist }))
+
+	companies := make([][]NamedCount, len(firms))
+	coinvestors := make([][]NamedCount, len(firms))
+	for i, f := range firms {
+		companies[i], coinvestors[i] = f.Companies, f.Coinvestors
+	}
+	c.SharedCompanies = shared(companies)
+	c.SharedCoinvestors = shared(coinvestors)
+	return c
+}
+
+// compareDists lines up the buckets of dists in the given order, leaving out
+// buckets that are empty for every firm.
+func compareDists(order []string, dists []BucketedInts) ComparedDist {
+	var c ComparedDist
+	c.Rows = make([]ComparedRow, 0, len(order))
+	for _, name := range order {
+		row := ComparedRow{Name: name, Counts: make([]int64, len(dists))}
+		var present bool
+		for i, d := range dists {
+			for _, b := range d.Buckets {
+				if b.Name == name {
+					row.Counts[i] = b.Count
+				}
+			}
+			if row.Counts[i] > c.Max {
+				c.Max = row.Counts[i]
+			}
+			present = present || row.Counts[i] > 0
+		}
+		if present {
+			c.Rows = append(c.Rows, row)
+		}
+	}
+	return c
+}
+
+// shared returns the entries that are in more than one of lists.
+func shared(lists [][]NamedCount) []SharedCounts {
+	byLink := make(map[string]*SharedCounts)
+	for i, l := range lists {
+		for _, n := range l {
+			s, ok := byLink[n.Permalink]
+			if !ok {
+				s = &SharedCounts{Permalink: n.Permalink, Name: n.Name, Rounds: make([]int, len(lists))}
+				byLink[n.Permalink] = s
+			}
+			s.Rounds[i] = n.Rounds
+		}
+	}
+
+	res := make(sharedCounts, 0)
+	for _, s := range byLink {
+		if s.firms() > 1 {
+			res = append(res, *s)
+		}
+	}
+	sort.Sort(res)
+	return res
+}
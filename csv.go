@@ -0,0 +1,353 @@
+package fundhawk
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func init() {
+	RegisterSource("csv", func(c SourceConfig) Source { return NewCSVSource(c.Path) })
+}
+
+// CSVSource loads the CrunchBase bulk export (organizations, funding_rounds
+// and investments, plus acquisitions and ipos if present) from a directory
+// and joins it into the same VC, Round and CompanyProfile structures the v1
+// API JSON decodes into. Each table may be a .csv or a .tsv file.
+type CSVSource struct {
+	Path string
+
+	once  sync.Once
+	err   error
+	orgs  map[string]*csvOrg   // by uuid
+	links map[string]string    // permalink -> org uuid
+	funds map[string]*csvRound // by uuid
+	// investor uuid -> funding round uuids
+	investments map[string][]string
+	// funding round uuid -> lead investor uuids
+	leads map[string]map[string]bool
+}
+
+type csvOrg struct {
+	Name      string
+	Permalink string
+	Homepage  string
+	Overview  string
+	Category  string
+	Tags      string
+	Office    *Office
+
+	Acquisition *Acquisition
+	IPO         *IPO
+}
+
+type csvRound struct {
+	Round
+	ID string
+}
+
+func NewCSVSource(path string) *CSVSource {
+	return &CSVSource{Path: path}
+}
+
+func (s *CSVSource) ListFirms(page int, list *Permalinks) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	if page > 0 {
+		return nil
+	}
+
+	for uuid := range s.investments {
+		if org, ok := s.orgs[uuid]; ok {
+			*list = append(*list, Permalink{Link: org.Permalink})
+		}
+	}
+	sort.Sort(*list)
+	return nil
+}
+
+func (s *CSVSource) Firm(permalink string, vc *VC) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	uuid, ok := s.links[permalink]
+	if !ok {
+		return fmt.Errorf("csv: no organization %q", permalink)
+	}
+	org := s.orgs[uuid]
+
+	vc.Name = org.Name
+	vc.Permalink = org.Permalink
+	if org.Homepage != "" {
+		vc.URL = &org.Homepage
+	}
+	if org.Overview != "" {
+		o := template.HTML(template.HTMLEscapeString(org.Overview))
+		vc.Overview = &o
+	}
+	if org.Office != nil {
+		vc.Offices = []Office{*org.Office}
+	}
+
+	for _, id := range s.investments[uuid] {
+		r := s.funds[id].Round
+		vc.Investments = append(vc.Investments, Investment{Round: &r, Lead: s.leads[id][uuid]})
+	}
+	return nil
+}
+
+func (s *CSVSource) Company(permalink string, c *CompanyProfile) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	uuid, ok := s.links[permalink]
+	if !ok {
+		return fmt.Errorf("csv: no organization %q", permalink)
+	}
+	org := s.orgs[uuid]
+	c.Name, c.Permalink = org.Name, org.Permalink
+	c.Category, c.TagList = org.Category, org.Tags
+	c.Acquisition, c.IPO = org.Acquisition, org.IPO
+	if org.Office != nil {
+		c.Offices = []Office{*org.Office}
+	}
+	return nil
+}
+
+func (s *CSVSource) Round(id string, r *Round) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	f, ok := s.funds[id]
+	if !ok {
+		return fmt.Errorf("csv: no funding round %q", id)
+	}
+	*r = f.Round
+	return nil
+}
+
+func (s *CSVSource) load() error {
+	s.once.Do(func() { s.err = s.read() })
+	return s.err
+}
+
+func (s *CSVSource) read() error {
+	s.orgs = make(map[string]*csvOrg)
+	s.links = make(map[string]string)
+	s.funds = make(map[string]*csvRound)
+	s.investments = make(map[string][]string)
+	s.leads = make(map[string]map[string]bool)
+
+	err := s.readTable("organizations", true, func(row csvRow) {
+		org := &csvOrg{
+			Name:      row.Get("name"),
+			Permalink: row.Get("permalink"),
+			Homepage:  row.Get("homepage_url"),
+			Overview:  row.Get("short_description"),
+			Tags:      row.Get("category_list"),
+		}
+		// the bulk export has no single category; the first group stands in
+		org.Category = strings.ToLower(strings.SplitN(row.Get("category_groups_list"), ",", 2)[0])
+		if org.Category == "" {
+			org.Category = strings.ToLower(strings.SplitN(org.Tags, ",", 2)[0])
+		}
+		if row.Get("city") != "" || row.Get("country_code") != "" {
+			org.Office = &Office{
+				City:      row.Get("city"),
+				State:     row.Get("region"),
+				Country:   row.Get("country_code"),
+				Latitude:  csvFloat(row.Get("latitude")),
+				Longitude: csvFloat(row.Get("longitude")),
+			}
+		}
+		uuid := row.Get("uuid")
+		if org.Permalink == "" {
+			org.Permalink = uuid
+		}
+		s.orgs[uuid] = org
+		s.links[org.Permalink] = uuid
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.readTable("funding_rounds", true, func(row csvRow) {
+		org, ok := s.orgs[row.Get("org_uuid")]
+		if !ok {
+			return
+		}
+		r := &csvRound{ID: row.Get("uuid")}
+		r.Code = csvRoundCode(row.Get("investment_type"))
+		r.Company = Company{Name: org.Name, Permalink: org.Permalink}
+		r.Amount = csvFloat(row.Get("raised_amount_usd"))
+		r.Year, r.Month, r.Day = csvDate(row.Get("announced_on"))
+		s.funds[r.ID] = r
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.readTable("investments", true, func(row csvRow) {
+		id, investor := row.Get("funding_round_uuid"), row.Get("investor_uuid")
+		if _, ok := s.funds[id]; !ok {
+			return
+		}
+		// angel investors are people, and only organizations get a firm page
+		if _, ok := s.orgs[investor]; !ok {
+			return
+		}
+		s.investments[investor] = append(s.investments[investor], id)
+		if lead, _ := strconv.ParseBool(row.Get("is_lead_investor")); lead {
+			if s.leads[id] == nil {
+				s.leads[id] = make(map[string]bool)
+			}
+			s.leads[id][investor] = true
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.readTable("acquisitions", false, func(row csvRow) {
+		org, ok := s.orgs[row.Get("acquiree_uuid")]
+		if !ok {
+			return
+		}
+		a := &Acquisition{Currency: "USD", Price: csvFloat(row.Get("price_usd"))}
+		a.Year, a.Month, a.Day = csvDate(row.Get("acquired_on"))
+		if acquirer, ok := s.orgs[row.Get("acquirer_uuid")]; ok {
+			a.Acquirer = &Company{Name: acquirer.Name, Permalink: acquirer.Permalink}
+		}
+		org.Acquisition = a
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.readTable("ipos", false, func(row csvRow) {
+		org, ok := s.orgs[row.Get("org_uuid")]
+		if !ok {
+			return
+		}
+		i := &IPO{Currency: "USD", Valuation: csvFloat(row.Get("valuation_price_usd")), Symbol: row.Get("stock_symbol")}
+		i.Year, i.Month, i.Day = csvDate(row.Get("went_public_on"))
+		org.IPO = i
+	})
+	return err
+}
+
+func csvFloat(s string) *float64 {
+	if a, err := strconv.ParseFloat(s, 64); err == nil {
+		return &a
+	}
+	return nil
+}
+
+// csvDate splits a YYYY-MM-DD date into the separate fields the v1 API uses.
+// Parts that are missing or malformed are nil.
+func csvDate(s string) (year, month, day *int) {
+	parts := strings.SplitN(s, "-", 3)
+	fields := []**int{&year, &month, &day}
+	for i, p := range parts {
+		if n, err := strconv.Atoi(p); err == nil && n > 0 {
+			*fields[i] = &n
+		}
+	}
+	return
+}
+
+// csvRoundCode maps a bulk export investment_type to the v1 round_code.
+func csvRoundCode(t string) string {
+	switch {
+	case strings.HasPrefix(t, "series_") && len(t) == len("series_")+1:
+		return t[len("series_"):]
+	case t == "debt_financing":
+		return "debt_round"
+	case t == "series_unknown", t == "undisclosed", t == "":
+		return "unattributed"
+	}
+	return t
+}
+
+// csvRow is a record keyed by its table's header.
+type csvRow struct {
+	header map[string]int
+	record []string
+}
+
+func (r csvRow) Get(col string) string {
+	if i, ok := r.header[col]; ok && i < len(r.record) {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
+
+// csvTableFormats are the file extensions a table may be stored with and
+// their separators, in the order they are looked for.
+var csvTableFormats = []struct {
+	ext   string
+	comma rune
+}{
+	{".csv", ','},
+	{".tsv", '\t'},
+}
+
+// readTable calls fn for every row of the named table, which may be stored as
+// <name>.csv or <name>.tsv; if both exist the .csv is read. A missing table
+// is only an error if required.
+func (s *CSVSource) readTable(name string, required bool, fn func(csvRow)) error {
+	var f *os.File
+	var comma rune
+	for _, t := range csvTableFormats {
+		var err error
+		f, err = os.Open(filepath.Join(s.Path, name+t.ext))
+		if err == nil {
+			comma = t.comma
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if f == nil && required {
+		return fmt.Errorf("csv: neither %s.csv nor %s.tsv found in %s", name, name, s.Path)
+	}
+	if f == nil {
+		return nil
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+
+	head, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("csv: %s: %s", name, err)
+	}
+	row := csvRow{header: make(map[string]int, len(head))}
+	for i, h := range head {
+		row.header[strings.TrimPrefix(strings.TrimSpace(h), "\ufeff")] = i
+	}
+
+	for {
+		row.record, err = r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("csv: %s: %s", name, err)
+		}
+		fn(row)
+	}
+}
@@ -0,0 +1,166 @@
+package fundhawk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTables writes each table's rows, joined by newlines, to dir.
+func writeTables(t *testing.T, dir string, tables map[string][]string) {
+	for name, rows := range tables {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(rows, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCSVRoundCode(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"seed", "seed"},
+		{"angel", "angel"},
+		{"series_a", "a"},
+		{"series_c", "c"},
+		{"series_unknown", "unattributed"},
+		{"undisclosed", "unattributed"},
+		{"", "unattributed"},
+		{"debt_financing", "debt_round"},
+		{"convertible_note", "convertible_note"},
+	} {
+		if got := csvRoundCode(tt.in); got != tt.want {
+			t.Errorf("csvRoundCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	dir := t.TempDir()
+	writeTables(t, dir, map[string][]string{
+		"organizations.csv": {
+			"uuid,name,permalink,category_list,category_groups_list,city,region,country_code",
+			"o1,Acme VC,acme-vc,,,Boston,MA,USA",
+			"o2,Widget Co,widget,\"SaaS,Enterprise\",Software,Cambridge,MA,USA",
+			"o3,Quiet Capital,quiet,,,,,",
+			"o4,Buyer Inc,buyer,,,,,",
+		},
+		"funding_rounds.tsv": {
+			"uuid\torg_uuid\tinvestment_type\tannounced_on\traised_amount_usd",
+			"r1\to2\tseed\t2010-03-01\t500000",
+			"r2\to2\tseries_b\t2012\t",
+			"r3\tgone\tseries_a\t2011-01-01\t1000000",
+		},
+		"investments.csv": {
+			"funding_round_uuid,investor_uuid,is_lead_investor",
+			"r1,o1,t",
+			"r2,o1,f",
+			// a person, not an organization
+			"r1,p1,f",
+			// a round of a company not in the export
+			"r3,o3,t",
+		},
+		"acquisitions.csv": {
+			"acquiree_uuid,acquirer_uuid,acquired_on,price_usd",
+			"o2,o4,2014-06-01,250000000",
+		},
+	})
+	s := NewCSVSource(dir)
+
+	var list Permalinks
+	if err := s.ListFirms(0, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Link != "acme-vc" {
+		t.Errorf("ListFirms: got %v, want only acme-vc", list)
+	}
+
+	vc := &VC{}
+	if err := s.Firm("acme-vc", vc); err != nil {
+		t.Fatal(err)
+	}
+	if vc.Name != "Acme VC" || len(vc.Offices) != 1 || vc.Offices[0].City != "Boston" {
+		t.Errorf("Firm: got %+v", vc)
+	}
+	if len(vc.Investments) != 2 {
+		t.Fatalf("Firm: got %d investments, want 2", len(vc.Investments))
+	}
+	for _, tt := range []struct {
+		code   string
+		year   int
+		month  bool
+		amount bool
+		lead   bool
+	}{
+		{"seed", 2010, true, true, true},
+		{"b", 2012, false, false, false},
+	} {
+		var inv *Investment
+		for i := range vc.Investments {
+			if vc.Investments[i].Round.Code == tt.code {
+				inv = &vc.Investments[i]
+			}
+		}
+		if inv == nil {
+			t.Errorf("Firm: no %s round", tt.code)
+			continue
+		}
+		r := inv.Round
+		if r.Company.Permalink != "widget" || r.Year == nil || *r.Year != tt.year ||
+			(r.Month != nil) != tt.month || (r.Amount != nil) != tt.amount || inv.Lead != tt.lead {
+			t.Errorf("Firm: %s round: got %+v, lead %v", tt.code, r, inv.Lead)
+		}
+	}
+
+	c := &CompanyProfile{}
+	if err := s.Company("widget", c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Category != "software" || c.TagList != "SaaS,Enterprise" || c.IPO != nil {
+		t.Errorf("Company: got %+v", c)
+	}
+	if a := c.Acquisition; a == nil || a.Acquirer == nil || a.Acquirer.Permalink != "buyer" || *a.Year != 2014 {
+		t.Errorf("Company: got acquisition %+v", a)
+	}
+}
+
+func TestCSVTables(t *testing.T) {
+	org := "uuid,name,permalink\no1,Acme VC,acme-vc\n"
+	for _, tt := range []struct {
+		name   string
+		files  map[string]string
+		want   string
+		errors bool
+	}{
+		{"csv", map[string]string{"organizations.csv": org}, "Acme VC", false},
+		{"tsv", map[string]string{"organizations.tsv": strings.Replace(org, ",", "\t", -1)}, "Acme VC", false},
+		{"csv first", map[string]string{
+			"organizations.csv": org,
+			"organizations.tsv": "uuid\tname\tpermalink\no1\tOther VC\tacme-vc\n",
+		}, "Acme VC", false},
+		{"missing", map[string]string{}, "", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, body := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// only organizations is given, so the other required tables fail
+			s := NewCSVSource(dir)
+			err := s.load()
+			if tt.errors {
+				if err == nil || !strings.Contains(err.Error(), "neither organizations.csv nor organizations.tsv") {
+					t.Errorf("got error %v, want a missing organizations table", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "funding_rounds") {
+				t.Errorf("got error %v, want a missing funding_rounds table", err)
+			}
+			if org := s.orgs["o1"]; org == nil || org.Name != tt.want {
+				t.Errorf("got organization %+v, want %s", org, tt.want)
+			}
+		})
+	}
+}
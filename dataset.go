@@ -0,0 +1,451 @@
+package fundhawk
+
+import (
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dataset holds a set of firms and the rounds they took part in. A loader
+// fills it with Add or LoadFirm, Calculate derives the cross-firm metrics,
+// and the result is passed to the render stage. Datasets are independent of
+// each other, so several (e.g. two snapshots) can be built side by side.
+type Dataset struct {
+	mu sync.RWMutex
+
+	VCs      map[string]*VC
+	RoundVCs map[string]map[*VC]struct{}
+	Rounds   map[string]Round
+
+	// leadFlags holds, per round, the firms the source flagged as leads.
+	leadFlags map[string]map[*VC]bool
+
+	// Profiles holds the companies loaded with AddCompany or LoadCompany.
+	// Companies is filled in by Calculate.
+	Profiles  map[string]*CompanyProfile
+	Companies map[string]*CompanyHistory
+
+	// DataList and NamePrefixes are the search index: [permalink, name,
+	// section] triples, where section is "firms" or "companies", and for
+	// each word prefix the DataList positions of the names with a word
+	// starting with it.
+	DataList     [][]string
+	NamePrefixes map[string]WeightedIDs
+
+	// indexed holds the companies already in the search index.
+	indexed map[string]bool
+
+	// Connected is the leaderboard of the firms most central to the
+	// coinvestment network, by PageRank.
+	Connected []*VC
+
+	// MinYear and MaxYear bound the years that the per-year metrics cover,
+	// and MaxYear also ends the time windows. They must be set before any
+	// firm is added. MaxYear is taken to be under way, so it is left out of
+	// the yearly min, max, median and mean.
+	MinYear, MaxYear int
+
+	// Clusters are the syndicates found in the coinvestments made from
+	// ClusterFrom to ClusterTo, largest first. Either year may be 0 for
+	// MinYear or MaxYear.
+	ClusterFrom, ClusterTo int
+	Clusters               []*Cluster
+
+	// Peers is the group each firm's headline metrics are ranked within:
+	// PeersAll, PeersSize or PeersStage.
+	Peers string
+}
+
+func NewDataset() *Dataset {
+	return &Dataset{
+		VCs:          make(map[string]*VC),
+		RoundVCs:     make(map[string]map[*VC]struct{}),
+		Rounds:       make(map[string]Round),
+		Profiles:     make(map[string]*CompanyProfile),
+		leadFlags:    make(map[string]map[*VC]bool),
+		DataList:     [][]string{},
+		NamePrefixes: make(map[string]WeightedIDs),
+		indexed:      make(map[string]bool),
+		MinYear:      DefaultMinYear,
+		MaxYear:      time.Now().Year(),
+	}
+}
+
+// inYears reports whether y is from MinYear to MaxYear.
+func (d *Dataset) inYears(y int) bool {
+	return y >= d.MinYear && y <= d.MaxYear
+}
+
+// Loader fetches firms from a Source into a new Dataset.
+type Loader struct {
+	Source  Source
+	Workers int
+
+	// Log receives every fetch error as it happens, if set.
+	Log io.Writer
+
+	// MinYear and MaxYear are set on the Dataset, see there.
+	MinYear, MaxYear int
+
+	// Failures collects the firms that could not be fetched.
+	Failures *FailureLog
+
+	unchanged int32
+}
+
+func NewLoader(src Source, workers int) *Loader {
+	return &Loader{Source: src, Workers: workers, Failures: &FailureLog{}, MinYear: DefaultMinYear, MaxYear: time.Now().Year()}
+}
+
+// Load fetches every firm in list using Workers goroutines.
+func (l *Loader) Load(list Permalinks) *Dataset {
+	d := NewDataset()
+	d.MinYear, d.MaxYear = l.MinYear, l.MaxYear
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < l.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			for permalink := range queue {
+				err := d.LoadFirm(l.Source, permalink)
+				if err == ErrNotModified {
+					atomic.AddInt32(&l.unchanged, 1)
+				} else if err != nil {
+					logf(l.Log, "firm fetch error: %s - %s\n", permalink, err)
+					l.Failures.Add(permalink, err)
+				}
+			}
+			wg.Done()
+		}()
+	}
+
+	for _, p := range list {
+		queue <- p.Link
+	}
+	close(queue)
+	wg.Wait()
+	return d
+}
+
+// LoadCompanies fetches the profile of every company the dataset's firms
+// invested in. Companies the source has no data for are skipped.
+func (l *Loader) LoadCompanies(d *Dataset) {
+	list := d.CompanyPermalinks()
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < l.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			for permalink := range queue {
+				err := d.LoadCompany(l.Source, permalink)
+				if os.IsNotExist(err) || err == ErrNotModified {
+					continue
+				}
+				if err != nil {
+					logf(l.Log, "company fetch error: %s - %s\n", permalink, err)
+					l.Failures.Add("company/"+permalink, err)
+				}
+			}
+			wg.Done()
+		}()
+	}
+
+	for _, p := range list {
+		queue <- p
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// Unchanged returns the number of firms a SkipUnchanged source skipped.
+func (l *Loader) Unchanged() int {
+	return int(atomic.LoadInt32(&l.unchanged))
+}
+
+// LoadFirm fetches a firm from src and adds it to the dataset.
+func (d *Dataset) LoadFirm(src Source, permalink string) error {
+	vc := &VC{}
+	if err := src.Firm(permalink, vc); err != nil {
+		return err
+	}
+	d.Add(vc)
+	return nil
+}
+
+// LoadCompany fetches a company's profile from src and adds it to the
+// dataset.
+func (d *Dataset) LoadCompany(src Source, permalink string) error {
+	p := &CompanyProfile{}
+	if err := src.Company(permalink, p); err != nil {
+		return err
+	}
+	if p.Permalink == "" {
+		p.Permalink = permalink
+	}
+	d.AddCompany(p)
+	return nil
+}
+
+// AddCompany adds a company profile, to be joined with the company's rounds
+// by Calculate.
+func (d *Dataset) AddCompany(p *CompanyProfile) {
+	d.mu.Lock()
+	d.Profiles[p.Permalink] = p
+	d.mu.Unlock()
+}
+
+// CompanyPermalinks returns every company the dataset's firms invested in.
+func (d *Dataset) CompanyPermalinks() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	list := make([]string, 0)
+	for _, r := range d.Rounds {
+		if p := r.Company.Permalink; !seen[p] {
+			seen[p] = true
+			list = append(list, p)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Add computes a firm's own metrics and indexes its rounds. Firms without any
+// investments are left out. It is safe to call from several goroutines.
+func (d *Dataset) Add(vc *VC) {
+	if len(vc.Investments) == 0 {
+		return
+	}
+
+	vc.RoundsByCode = make(map[string]int64)
+	vc.RoundsByYear = make(map[int]int64)
+	vc.RoundsByCompany = make(map[Company]int64)
+	vc.CompaniesByYear = make(map[int]int64)
+	vc.RoundShares = make(IntSlice, 0, len(vc.Investments))
+	vc.RoundSizes = make(IntSlice, 0, len(vc.Investments))
+	vc.Partners = make(map[*VC]*Partner)
+	vc.PartnersByRound = make(map[string][]int64)
+
+	companiesByYear := make(map[int]map[string]bool)
+
+	for _, inv := range vc.Investments {
+		r := inv.Round
+		cp := r.Company.Permalink
+
+		var y int
+		if r.Year != nil {
+			y = *r.Year
+		}
+		rid := cp + ":" + strconv.Itoa(y) + ":" + r.Code
+
+		if r.Code == "debt_round" {
+			r.Code = "debt"
+		}
+		r.setDate()
+		vc.RoundsByCode[r.Code] += 1
+
+		if r.Year != nil && d.inYears(*r.Year) {
+			year := *r.Year
+			vc.RoundsByYear[year] += 1
+
+			if _, ok := companiesByYear[year]; !ok {
+				companiesByYear[year] = make(map[string]bool)
+			}
+			companiesByYear[year][cp] = true
+		}
+
+		vc.RoundsByCompany[r.Company] += 1
+
+		if inv.Round.Amount != nil && *inv.Round.Amount >= 1 {
+			vc.RoundSizes = append(vc.RoundSizes, int64(*inv.Round.Amount))
+		}
+
+		d.mu.Lock()
+		if _, exists := d.RoundVCs[rid]; !exists {
+			d.RoundVCs[rid] = make(map[*VC]struct{})
+		}
+		d.RoundVCs[rid][vc] = struct{}{}
+		d.Rounds[rid] = *r
+		if inv.Lead {
+			if d.leadFlags[rid] == nil {
+				d.leadFlags[rid] = make(map[*VC]bool)
+			}
+			d.leadFlags[rid][vc] = true
+		}
+		d.mu.Unlock()
+	}
+
+	vc.RoundSizes.Sort()
+
+	for year, companies := range companiesByYear {
+		vc.CompaniesByYear[year] = int64(len(companies))
+	}
+	vc.TotalCompanies = len(vc.RoundsByCompany)
+
+	vc.YearRoundSet = make(IntSlice, 0, len(vc.RoundsByYear))
+	for year, x := range vc.RoundsByYear {
+		if year < d.MaxYear {
+			vc.YearRoundSet = append(vc.YearRoundSet, int64(x))
+		}
+	}
+	vc.YearRoundSet.Sort()
+
+	vc.YearCompanySet = make(IntSlice, 0, len(vc.CompaniesByYear))
+	for year, x := range vc.CompaniesByYear {
+		if year < d.MaxYear {
+			vc.YearCompanySet = append(vc.YearCompanySet, int64(x))
+		}
+	}
+	vc.YearCompanySet.Sort()
+
+	vc.SeriesDist.Buckets = make([]BucketedInt, 0, len(vc.RoundsByCode))
+	for _, b := range RoundCodeBuckets {
+		if c, ok := vc.RoundsByCode[strings.ToLower(b)]; ok {
+			if c > vc.SeriesDist.Max {
+				vc.SeriesDist.Max = c
+			}
+			vc.SeriesDist.Buckets = append(vc.SeriesDist.Buckets, BucketedInt{b, c})
+		}
+	}
+
+	cs := make([]int64, 0, len(vc.RoundsByCompany))
+	for _, i := range vc.RoundsByCompany {
+		cs = append(cs, int64(i))
+	}
+	vc.RoundCountDist = RoundCountBuckets.Aggregate(cs)
+	vc.RaiseDist = RoundSizeBuckets.Aggregate(vc.RoundSizes)
+
+	d.mu.Lock()
+	d.VCs[vc.Permalink] = vc
+	d.DataList = append(d.DataList, []string{vc.Permalink, vc.Name, "firms"})
+	for prefix := range wordPrefixes(vc.Name) {
+		d.NamePrefixes[prefix] = append(d.NamePrefixes[prefix], len(d.DataList)-1)
+	}
+	d.mu.Unlock()
+}
+
+// Calculate computes the metrics that depend on more than one firm, such as
+// coinvestors and round shares. It must be called once every firm is added.
+func (d *Dataset) Calculate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for rid, vcs := range d.RoundVCs {
+		r := d.Rounds[rid]
+
+		agg := func(vc *VC) {
+			for v := range vcs {
+				if v.Permalink == vc.Permalink {
+					continue
+				}
+
+				if r.Year != nil && d.inYears(*r.Year) {
+					var p *Partner
+					var ok bool
+					if p, ok = vc.Partners[v]; !ok {
+						p = &Partner{VC: v}
+						vc.Partners[v] = p
+					}
+					vc.Partners[v].Rounds += 1
+
+					year := *r.Year
+					if p.FirstYear == 0 || p.FirstYear > year {
+						p.FirstYear = year
+					}
+					if p.LastYear == 0 || p.LastYear < year {
+						p.LastYear = year
+					}
+				}
+			}
+
+			if r.Amount != nil && *r.Amount >= 1 {
+				vc.RoundShares = append(vc.RoundShares, RoundInt(*r.Amount/float64(len(vcs))))
+			}
+
+			vc.PartnerCountSet = append(vc.PartnerCountSet, int64(len(vcs)))
+
+			if _, ok := vc.PartnersByRound[r.Code]; !ok {
+				vc.PartnersByRound[r.Code] = make([]int64, 0, 1)
+			}
+			vc.PartnersByRound[r.Code] = append(vc.PartnersByRound[r.Code], int64(len(vcs))-1)
+		}
+
+		for vc := range vcs {
+			agg(vc)
+		}
+	}
+
+	for _, vc := range d.VCs {
+		vc.RoundShares.Sort()
+		vc.ShareDist = RoundShareBuckets.Aggregate(vc.RoundShares)
+
+		vc.PartnerList = make(PartnerList, 0, len(vc.Partners))
+		for _, p := range vc.Partners {
+			if p.Rounds < 2 {
+				continue
+			}
+
+			var r int64
+			for y := p.FirstYear; y <= p.LastYear; y++ {
+				r += p.VC.RoundsByYear[y]
+			}
+			p.Percentage = int64(math.Floor((float64(p.Rounds) / float64(r)) * 100))
+			vc.PartnerList = append(vc.PartnerList, p)
+		}
+		sort.Sort(vc.PartnerList)
+
+		vc.InvestorRoundDist.Buckets = make([]BucketedInt, 0, len(vc.PartnersByRound))
+		for _, b := range RoundCodeBuckets {
+			if cs, ok := vc.PartnersByRound[strings.ToLower(b)]; ok {
+				c := RoundInt(Mean(cs))
+				if c > vc.InvestorRoundDist.Max {
+					vc.InvestorRoundDist.Max = c
+				}
+				vc.InvestorRoundDist.Buckets = append(vc.InvestorRoundDist.Buckets, BucketedInt{b, c})
+			}
+		}
+	}
+
+	d.calculateCompanies()
+	d.calculateExits()
+	d.calculateLeads()
+	d.calculateFollowOns()
+	d.calculateProgression()
+	d.calculateSectors()
+	d.calculateGeography()
+	d.calculateCentrality()
+	d.calculateClusters()
+	d.calculateSimilar()
+	d.calculateWindows()
+	d.calculatePercentiles()
+
+	for _, l := range d.NamePrefixes {
+		sort.Sort(weightedIDs{l, d})
+	}
+}
+
+// weightedIDs orders DataList positions by how many investments the firm
+// made, or how many rounds the company raised, most first.
+type weightedIDs struct {
+	WeightedIDs
+	d *Dataset
+}
+
+func (w weightedIDs) Less(i, j int) bool {
+	return w.weight(w.WeightedIDs[i]) > w.weight(w.WeightedIDs[j])
+}
+
+func (w weightedIDs) weight(id int) int {
+	e := w.d.DataList[id]
+	if e[2] == "companies" {
+		return len(w.d.Companies[e[0]].Rounds)
+	}
+	return len(w.d.VCs[e[0]].Investments)
+}
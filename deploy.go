@@ -1,113 +1,111 @@
-package main
+package fundhawk
 
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"flag"
-	"github.com/ncw/swift"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
-)
 
-var rs *swift.Connection
+	"github.com/ncw/swift"
+)
 
-var rsUsername = flag.String("rsuser", "", "Rackspace username")
-var rsApiKey = flag.String("rskey", "", "Rackspace API key")
-var rsBucket = flag.String("bucket", "", "Rackspace Cloud Files bucket")
-var rsAssetUrl = flag.String("asseturl", "", "Asset URL")
+// CloudFiles writes the site to a Rackspace Cloud Files container.
+type CloudFiles struct {
+	Conn         *swift.Connection
+	Container    string
+	CacheControl string
+}
 
-func PutCloudFile(path string, r io.Reader) error {
+func (c *CloudFiles) Put(path string, r io.Reader) error {
 	ext := filepath.Ext(path)
-	_, err := rs.ObjectPut(*rsBucket, path, r, false, "", contentTypes[ext], swift.Headers{"Cache-Control": "public, max-age=300"})
+	_, err := c.Conn.ObjectPut(c.Container, path, r, false, "", contentTypes[ext], swift.Headers{"Cache-Control": c.CacheControl})
 	return err
 }
 
-func AssetPath(a string) string {
-	if *upload {
-		return *rsAssetUrl + "/" + assets[a]
-	}
-
-	return "/assets/" + assets[a]
+// AssetPath returns the URL of the fingerprinted copy of asset a.
+func (s *Site) AssetPath(a string) string {
+	return s.AssetURL + "/" + s.assets[a]
 }
 
 var jsAssets = []string{"lodash.js", "reqwest.js", "search.coffee"}
-var assets = map[string]string{"bootstrap.min.css": "", "style.css": "", "application.js": ""}
+var assetFiles = []string{"bootstrap.min.css", "style.css", "application.js"}
 var contentTypes = map[string]string{
 	".css":  "text/css",
 	".js":   "text/javascript",
 	".txt":  "text/plain",
 	".xml":  "text/xml",
+	".json": "application/json",
 	".gif":  "image/gif",
 	".html": "text/html; charset=utf-8",
 }
 
-func compileJS() {
+func (s *Site) compileJS() error {
 	out, err := ioutil.TempFile("", "")
+	if err != nil {
+		return err
+	}
 	defer out.Close()
 	defer os.Remove(out.Name())
 
 	for _, js := range jsAssets {
-		f, err := os.Open("assets/" + js)
+		f, err := os.Open(filepath.Join(s.Assets, js))
+		if err != nil {
+			return err
+		}
 		defer f.Close()
 		if filepath.Ext(js) == ".coffee" {
 			coffee := exec.Command("coffee", "-c", "--stdio")
 			coffee.Stdin = f
 			coffee.Stdout = out
-			err = coffee.Run()
-			MaybePanic(err)
+			if err := coffee.Run(); err != nil {
+				return err
+			}
 			continue
 		}
-		_, err = io.Copy(out, f)
-		MaybePanic(err)
+		if _, err := io.Copy(out, f); err != nil {
+			return err
+		}
 	}
 
-	err = exec.Command("uglifyjs", "-m", "-c", "-o", "assets/application.js", out.Name()).Run()
-	MaybePanic(err)
+	return exec.Command("uglifyjs", "-m", "-c", "-o", filepath.Join(s.Assets, "application.js"), out.Name()).Run()
 }
 
-func writeAssets() {
-	compileJS()
-	for a := range assets {
-		f, err := os.Open("assets/" + a)
-		MaybePanic(err)
-		defer f.Close()
-
-		h := sha256.New()
-		_, err = io.Copy(h, f)
-		MaybePanic(err)
-		hash := h.Sum(nil)
-		f.Seek(0, 0)
-
-		ext := filepath.Ext(a)
-		name := a[:len(a)-len(ext)] + "-" + hex.EncodeToString(hash[:4]) + ext
-
-		assets[a] = name
-		if *upload {
-			_, err = rs.ObjectPut(*rsBucket+"-assets", name, f, false, "", contentTypes[ext], swift.Headers{"Cache-Control": "public, max-age=31556925"})
-		} else {
-			err = os.MkdirAll("output/assets", os.ModeDir|os.ModePerm)
-			MaybePanic(err)
-			err = os.MkdirAll("output/firms", os.ModeDir|os.ModePerm)
-			MaybePanic(err)
+// WriteAssets compiles the JavaScript and writes every asset to AssetOut
+// under a name fingerprinted with its content hash.
+func (s *Site) WriteAssets() error {
+	if err := s.compileJS(); err != nil {
+		return err
+	}
 
-			w, err := os.Create("output/assets/" + name)
-			MaybePanic(err)
-			_, err = io.Copy(w, f)
-			w.Close()
+	s.assets = make(map[string]string, len(assetFiles))
+	for _, a := range assetFiles {
+		if err := s.writeAsset(a); err != nil {
+			return err
 		}
-		MaybePanic(err)
 	}
+	return nil
 }
 
-func init() {
-	flag.Parse()
+func (s *Site) writeAsset(a string) error {
+	f, err := os.Open(filepath.Join(s.Assets, a))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-	if *upload {
-		rs = &swift.Connection{UserName: *rsUsername, ApiKey: *rsApiKey, AuthUrl: "https://identity.api.rackspacecloud.com/v1.0"}
-		err := rs.Authenticate()
-		MaybePanic(err)
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
 	}
+	hash := h.Sum(nil)
+	f.Seek(0, 0)
+
+	ext := filepath.Ext(a)
+	name := a[:len(a)-len(ext)] + "-" + hex.EncodeToString(hash[:4]) + ext
+
+	s.assets[a] = name
+	return s.AssetOut.Put(name, f)
 }
@@ -0,0 +1,18 @@
+// Package fundhawk does VC analytics on CrunchBase data.
+//
+// Firms are fetched from a Source (the CrunchBase API, a local mirror of it
+// or the bulk CSV export) by a Loader into a Dataset. Calculate derives the
+// metrics that span firms, such as coinvestors, and a Site renders the
+// result as static pages:
+//
+//	src, err := fundhawk.NewSource("file", fundhawk.SourceConfig{Path: "./data"})
+//	...
+//	list, err := fundhawk.FirmList(src, "", os.Stdout)
+//	...
+//	d := fundhawk.NewLoader(src, 40).Load(list)
+//	d.Calculate()
+//	err = fundhawk.NewSite(fundhawk.DirOutput("output"), fundhawk.DirOutput("output/assets"), "/assets").Render(d)
+//
+// The statistics helpers (Median, Mean, ValueBuckets and friends) work on
+// plain int64 slices and can be used on their own.
+package fundhawk
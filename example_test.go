@@ -0,0 +1,56 @@
+package fundhawk_test
+
+import (
+	"fmt"
+
+	"github.com/jedchristiansen/fundhawk"
+)
+
+func ExampleMedian() {
+	fmt.Println(fundhawk.Median([]int64{1, 3, 4, 10}))
+	// Output: 3.5
+}
+
+func ExampleValueBuckets_Aggregate() {
+	sizes := []int64{50000, 750000, 2000000, 2500000, 40000000}
+	dist := fundhawk.RoundSizeBuckets.Aggregate(sizes)
+	for _, b := range dist.Buckets {
+		fmt.Printf("%s: %d\n", b.Name, b.Count)
+	}
+	// Output:
+	// <100k: 1
+	// 500k - 1m: 1
+	// 1 - 3m: 2
+	// >30m: 1
+}
+
+func ExampleBuckets() {
+	for _, b := range fundhawk.Buckets("<1m", "1 - 5m", ">5m") {
+		fmt.Println(b.Name, b.Min)
+	}
+	// Output:
+	// <1m 0
+	// 1 - 5m 1000000
+	// >5m 5000000
+}
+
+func ExampleDataset() {
+	year, amount := 2010, 4e6
+	round := fundhawk.Round{
+		Code:    "a",
+		Year:    &year,
+		Amount:  &amount,
+		Company: fundhawk.Company{Name: "Widget", Permalink: "widget"},
+	}
+
+	d := fundhawk.NewDataset()
+	for _, name := range []string{"acme", "beta"} {
+		r := round
+		d.Add(&fundhawk.VC{Name: name, Permalink: name, Investments: []fundhawk.Investment{{Round: &r}}})
+	}
+	d.Calculate()
+
+	acme := d.VCs["acme"]
+	fmt.Println(acme.TotalCompanies, acme.SeriesDist.Buckets, acme.RoundShares)
+	// Output: 1 [{A 1}] [2000000]
+}
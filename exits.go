@@ -0,0 +1,152 @@
+package fundhawk
+
+import (
+	"sort"
+	"strings"
+)
+
+// CompanyProfile is a company entity as the v1 API returns it. Unlike
+// Company, which identifies the company a round belongs to, it carries what
+// is known about the company itself.
+type CompanyProfile struct {
+	Name        string       `json:"name"`
+	Permalink   string       `json:"permalink"`
+	Category    string       `json:"category_code"`
+	TagList     string       `json:"tag_list"`
+	Offices     []Office     `json:"offices"`
+	Acquisition *Acquisition `json:"acquisition"`
+	IPO         *IPO         `json:"ipo"`
+}
+
+type Acquisition struct {
+	Price    *float64 `json:"price_amount"`
+	Currency string   `json:"price_currency_code"`
+	Year     *int     `json:"acquired_year"`
+	Month    *int     `json:"acquired_month"`
+	Day      *int     `json:"acquired_day"`
+	Acquirer *Company `json:"acquiring_company"`
+}
+
+type IPO struct {
+	Valuation *float64 `json:"valuation_amount"`
+	Currency  string   `json:"valuation_currency_code"`
+	Year      *int     `json:"pub_year"`
+	Month     *int     `json:"pub_month"`
+	Day       *int     `json:"pub_day"`
+	Symbol    string   `json:"stock_symbol"`
+}
+
+// Exit is the outcome of a company that was acquired or went public. A
+// company that did both exited with whichever came first, or with the IPO if
+// they fell in the same year or either year is unknown.
+type Exit struct {
+	Company  Company
+	IPO      bool
+	Acquirer *Company
+	Symbol   string
+	Year     int
+	Value    int64
+}
+
+// Exit returns the company's exit, or nil if it has not exited.
+func (p *CompanyProfile) Exit() *Exit {
+	if p == nil {
+		return nil
+	}
+	c := Company{Name: p.Name, Permalink: p.Permalink}
+	var ipo, acquisition *Exit
+	if i := p.IPO; i != nil {
+		ipo = &Exit{Company: c, IPO: true, Symbol: i.Symbol}
+		if i.Year != nil {
+			ipo.Year = *i.Year
+		}
+		if i.Valuation != nil && (i.Currency == "" || i.Currency == "USD") {
+			ipo.Value = RoundInt(*i.Valuation)
+		}
+	}
+	if a := p.Acquisition; a != nil {
+		acquisition = &Exit{Company: c, Acquirer: a.Acquirer}
+		if a.Year != nil {
+			acquisition.Year = *a.Year
+		}
+		if a.Price != nil && (a.Currency == "" || a.Currency == "USD") {
+			acquisition.Value = RoundInt(*a.Price)
+		}
+	}
+
+	switch {
+	case ipo == nil:
+		return acquisition
+	case acquisition != nil && ipo.Year != 0 && acquisition.Year != 0 && acquisition.Year < ipo.Year:
+		return acquisition
+	}
+	return ipo
+}
+
+type ExitList []*Exit
+
+func (e ExitList) Len() int { return len(e) }
+func (e ExitList) Less(i, j int) bool {
+	if e[i].Value != e[j].Value {
+		return e[i].Value > e[j].Value
+	}
+	return e[i].Year > e[j].Year
+}
+func (e ExitList) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+
+const notableExits = 10
+
+// calculateExits computes each firm's outcome metrics from the company
+// profiles: exits, exit rate by the series the firm entered at, years from
+// its first investment to the exit and its largest exits.
+func (d *Dataset) calculateExits() {
+	for _, vc := range d.VCs {
+		vc.Exits = 0
+		vc.YearsToExit = make(IntSlice, 0)
+		vc.NotableExits = make(ExitList, 0)
+
+		entered := make(map[string]int64)
+		exited := make(map[string]int64)
+		for company := range vc.RoundsByCompany {
+			c := d.Companies[company.Permalink]
+			var entry *CompanyRound
+			for i := range c.Rounds {
+				if c.Rounds[i].Has(vc) {
+					entry = &c.Rounds[i]
+					break
+				}
+			}
+			entered[entry.Round.Code]++
+
+			e := c.Profile.Exit()
+			if e == nil {
+				continue
+			}
+			vc.Exits++
+			exited[entry.Round.Code]++
+			if e.Year > 0 && entry.Round.Year != nil && e.Year >= *entry.Round.Year {
+				vc.YearsToExit = append(vc.YearsToExit, int64(e.Year-*entry.Round.Year))
+			}
+			vc.NotableExits = append(vc.NotableExits, e)
+		}
+		vc.YearsToExit.Sort()
+
+		sort.Sort(vc.NotableExits)
+		if len(vc.NotableExits) > notableExits {
+			vc.NotableExits = vc.NotableExits[:notableExits]
+		}
+
+		vc.ExitRateDist = BucketedInts{Buckets: make([]BucketedInt, 0, len(entered))}
+		for _, b := range RoundCodeBuckets {
+			n, ok := entered[strings.ToLower(b)]
+			if !ok {
+				continue
+			}
+			rate := RoundInt(float64(exited[strings.ToLower(b)]) * 100 / float64(n))
+			if rate > vc.ExitRateDist.Max {
+				vc.ExitRateDist.Max = rate
+			}
+			vc.ExitRateDist.Buckets = append(vc.ExitRateDist.Buckets, BucketedInt{b, rate})
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package fundhawk
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// exportDists are the distributions of the firm pages, which get a column
+// per bucket in the export. Distributions without fixed buckets get a column
+// for every bucket name any firm has, in alphabetical order.
+var exportDists = []struct {
+	name    string
+	buckets []string
+	get     func(*VC) BucketedInts
+}{
+	{"series", RoundCodeBuckets, func(vc *VC) BucketedInts { return vc.SeriesDist }},
+	{"rounds_per_company", RoundCountBuckets.Names(), func(vc *VC) BucketedInts { return vc.RoundCountDist }},
+	{"round_size", RoundSizeBuckets.Names(), func(vc *VC) BucketedInts { return vc.RaiseDist }},
+	{"round_share", RoundShareBuckets.Names(), func(vc *VC) BucketedInts { return vc.ShareDist }},
+	{"coinvestors_by_round", RoundCodeBuckets, func(vc *VC) BucketedInts { return vc.InvestorRoundDist }},
+	{"lead_rate", RoundCodeBuckets, func(vc *VC) BucketedInts { return vc.LeadRateDist }},
+	{"follow_on_rate", RoundCodeBuckets, func(vc *VC) BucketedInts { return vc.FollowOnDist }},
+	{"exit_rate", RoundCodeBuckets, func(vc *VC) BucketedInts { return vc.ExitRateDist }},
+	{"months_to_next_round", NextRoundBuckets.Names(), func(vc *VC) BucketedInts { return vc.NextRoundDist }},
+	{"sector", nil, func(vc *VC) BucketedInts { return vc.SectorDist }},
+	{"tag", nil, func(vc *VC) BucketedInts { return vc.TagDist }},
+	{"country_share", nil, func(vc *VC) BucketedInts { return vc.CountryDist }},
+	{"metro_share", nil, func(vc *VC) BucketedInts { return vc.MetroDist }},
+}
+
+// Export returns a table of every firm's summary metrics, one row per firm
+// ordered by permalink. All columns but the first two are numbers.
+func (d *Dataset) Export() (header []string, rows [][]string) {
+	header = []string{"permalink", "name", "companies", "investments", "rounds_led", "lead_rate",
+		"follow_on_rate", "avg_rounds_held", "graduation_rate", "exits", "local_rate",
+		"coinvestors", "pagerank_percentile"}
+	for _, s := range []string{"companies_per_year", "rounds_per_year", "round_sizes", "round_shares"} {
+		header = append(header, s+"_min", s+"_max", s+"_median", s+"_mean")
+	}
+
+	vcs := make([]*VC, 0, len(d.VCs))
+	for _, vc := range d.VCs {
+		vcs = append(vcs, vc)
+	}
+	sort.Sort(vcsByPermalink(vcs))
+
+	columns := make([][]string, len(exportDists))
+	for i, dist := range exportDists {
+		columns[i] = dist.buckets
+		if dist.buckets == nil {
+			columns[i] = bucketNames(vcs, dist.get)
+		}
+		for _, b := range columns[i] {
+			header = append(header, dist.name+" "+b)
+		}
+	}
+
+	itoa := func(n int64) string { return strconv.FormatInt(n, 10) }
+	ftoa := func(f float64) string { return strconv.FormatFloat(RoundFloat(f, 2), 'f', -1, 64) }
+	rows = make([][]string, 0, len(vcs))
+	for _, vc := range vcs {
+		row := []string{vc.Permalink, vc.Name,
+			itoa(int64(vc.TotalCompanies)),
+			itoa(int64(len(vc.Investments))),
+			itoa(int64(vc.RoundsLed)),
+			itoa(vc.LeadRate),
+			itoa(vc.FollowOnRate),
+			ftoa(vc.AvgRoundsHeld),
+			itoa(vc.GraduationRate),
+			itoa(int64(vc.Exits)),
+			itoa(vc.LocalRate),
+			itoa(int64(vc.Centrality.Degree)),
+			itoa(vc.Centrality.Percentile),
+		}
+		for _, s := range []IntSlice{vc.YearCompanySet, vc.YearRoundSet, vc.RoundSizes, vc.RoundShares} {
+			row = append(row, itoa(First(s)), itoa(Last(s)), ftoa(Median(s)), ftoa(Mean(s)))
+		}
+		for i, dist := range exportDists {
+			counts := make(map[string]int64)
+			for _, b := range dist.get(vc).Buckets {
+				counts[b.Name] = b.Count
+			}
+			for _, b := range columns[i] {
+				row = append(row, itoa(counts[b]))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// bucketNames returns every bucket name of a distribution across the firms,
+// sorted.
+func bucketNames(vcs []*VC, get func(*VC) BucketedInts) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, vc := range vcs {
+		for _, b := range get(vc).Buckets {
+			if !seen[b.Name] {
+				seen[b.Name] = true
+				names = append(names, b.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteCSV writes a table as CSV.
+func WriteCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// xlsxParts are the fixed parts of a workbook with a single worksheet.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Firms" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`},
+	// style 1 is the bold header
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`},
+}
+
+// WriteXLSX writes a table as an Excel workbook with a bold, frozen header
+// row. Cells after the first two columns are written as numbers if they
+// parse as one.
+func WriteXLSX(w io.Writer, header []string, rows [][]string) error {
+	z := zip.NewWriter(w)
+	for _, p := range xlsxParts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(f, header, rows); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+func writeSheet(w io.Writer, header []string, rows [][]string) error {
+	fmt.Fprint(w, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	fmt.Fprint(w, `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	fmt.Fprint(w, `<sheetData>`)
+	writeRow(w, 1, header, true)
+	for i, row := range rows {
+		writeRow(w, i+2, row, false)
+	}
+	_, err := fmt.Fprint(w, `</sheetData></worksheet>`)
+	return err
+}
+
+func writeRow(w io.Writer, n int, cells []string, header bool) {
+	fmt.Fprintf(w, `<row r="%d">`, n)
+	for i, c := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(n)
+		if _, err := strconv.ParseFloat(c, 64); err == nil && !header && i > 1 {
+			fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, c)
+			continue
+		}
+		style := ""
+		if header {
+			style = ` s="1"`
+		}
+		fmt.Fprintf(w, `<c r="%s" t="inlineStr"%s><is><t>`, ref, style)
+		xml.EscapeText(w, []byte(c))
+		fmt.Fprint(w, `</t></is></c>`)
+	}
+	fmt.Fprint(w, `</row>`)
+}
+
+// xlsxColumn is the spreadsheet name of the zero based column i: A, B, ...,
+// Z, AA, AB, ...
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,122 @@
+package fundhawk
+
+import "strings"
+
+// StageMatrix shows how often a firm joins a company's next round, by the
+// series of the round it was in (rows) and the series of the next round
+// (columns). Stages lists the row and column names in series order.
+type StageMatrix struct {
+	Stages []string
+	Cells  [][]StageCell
+}
+
+// StageCell counts the rounds a firm joined that were followed by a round of
+// the column's series, and how many of those next rounds it joined too.
+type StageCell struct {
+	Rounds    int64
+	FollowOns int64
+	Rate      int64
+}
+
+// calculateFollowOns computes each firm's follow-on behaviour: how likely it
+// is to join a company's next round after its first check, that rate by the
+// series it entered at, the stage transition matrix across all its rounds
+// and the average number of rounds it holds per company. Undated rounds,
+// which sort last, are not known to follow any round and make no
+// transitions.
+func (d *Dataset) calculateFollowOns() {
+	for _, vc := range d.VCs {
+		var next, followed int64
+		entered := make(map[string]int64)
+		enteredFollowed := make(map[string]int64)
+		transitions := make(map[string]map[string]*StageCell)
+		held := make([]int64, 0, len(vc.RoundsByCompany))
+
+		for company, n := range vc.RoundsByCompany {
+			held = append(held, n)
+			rounds := d.Companies[company.Permalink].Rounds
+
+			entry := -1
+			for i := 0; i+1 < len(rounds); i++ {
+				if !rounds[i].Has(vc) || rounds[i+1].Round.Year == nil {
+					continue
+				}
+				from, to := rounds[i].Round.Code, rounds[i+1].Round.Code
+				joined := rounds[i+1].Has(vc)
+
+				if transitions[from] == nil {
+					transitions[from] = make(map[string]*StageCell)
+				}
+				if transitions[from][to] == nil {
+					transitions[from][to] = &StageCell{}
+				}
+				transitions[from][to].Rounds++
+				if joined {
+					transitions[from][to].FollowOns++
+				}
+
+				if entry == -1 {
+					entry = i
+					next++
+					entered[from]++
+					if joined {
+						followed++
+						enteredFollowed[from]++
+					}
+				}
+			}
+		}
+
+		vc.FollowOnRate = 0
+		if next > 0 {
+			vc.FollowOnRate = RoundInt(float64(followed) * 100 / float64(next))
+		}
+		vc.AvgRoundsHeld = Mean(held)
+
+		vc.FollowOnDist = BucketedInts{Buckets: make([]BucketedInt, 0, len(entered))}
+		for _, b := range RoundCodeBuckets {
+			n, ok := entered[strings.ToLower(b)]
+			if !ok {
+				continue
+			}
+			rate := RoundInt(float64(enteredFollowed[strings.ToLower(b)]) * 100 / float64(n))
+			if rate > vc.FollowOnDist.Max {
+				vc.FollowOnDist.Max = rate
+			}
+			vc.FollowOnDist.Buckets = append(vc.FollowOnDist.Buckets, BucketedInt{b, rate})
+		}
+
+		vc.StageMatrix = stageMatrix(transitions)
+	}
+}
+
+func stageMatrix(transitions map[string]map[string]*StageCell) StageMatrix {
+	present := make(map[string]bool)
+	for from, tos := range transitions {
+		present[from] = true
+		for to := range tos {
+			present[to] = true
+		}
+	}
+
+	codes := make([]string, 0, len(present))
+	m := StageMatrix{}
+	for _, b := range RoundCodeBuckets {
+		if present[strings.ToLower(b)] {
+			codes = append(codes, strings.ToLower(b))
+			m.Stages = append(m.Stages, b)
+		}
+	}
+
+	m.Cells = make([][]StageCell, len(codes))
+	for i, from := range codes {
+		m.Cells[i] = make([]StageCell, len(codes))
+		for j, to := range codes {
+			if c := transitions[from][to]; c != nil {
+				c.Rate = RoundInt(float64(c.FollowOns) * 100 / float64(c.Rounds))
+				m.Cells[i][j] = *c
+			}
+		}
+	}
+	return m
+}
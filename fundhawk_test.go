@@ -0,0 +1,109 @@
+package fundhawk
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pagedSource serves a firm list in pages, repeating the last page like the
+// API. It fails the page in fail, if set, and records the pages requested.
+type pagedSource struct {
+	pages     [][]string
+	fail      int
+	requested []int
+}
+
+var errPage = errors.New("page failed")
+
+func (s *pagedSource) ListFirms(page int, list *Permalinks) error {
+	s.requested = append(s.requested, page)
+	if s.fail > 0 && page == s.fail {
+		return errPage
+	}
+	if page >= len(s.pages) {
+		page = len(s.pages) - 1
+	}
+	for _, p := range s.pages[page] {
+		*list = append(*list, Permalink{Link: p})
+	}
+	return nil
+}
+
+func (s *pagedSource) Firm(string, *VC) error                { return errors.New("no firms") }
+func (s *pagedSource) Company(string, *CompanyProfile) error { return errors.New("no companies") }
+func (s *pagedSource) Round(string, *Round) error            { return errors.New("no rounds") }
+
+func links(list Permalinks) string {
+	var s []string
+	for _, p := range list {
+		s = append(s, p.Link)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestFirmList(t *testing.T) {
+	src := &pagedSource{pages: [][]string{{"a", "b"}, {"b", "c"}, {"d"}}}
+	list, err := FirmList(src, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := links(list); got != "a,b,c,d" {
+		t.Errorf("got %s, want a,b,c,d", got)
+	}
+	// the repeated last page adds nothing and ends the list
+	if want := []int{0, 1, 2, 3}; !reflect.DeepEqual(src.requested, want) {
+		t.Errorf("requested pages %v, want %v", src.requested, want)
+	}
+}
+
+func TestFirmListResume(t *testing.T) {
+	cursor := filepath.Join(t.TempDir(), "list.cursor")
+	pages := [][]string{{"a"}, {"b"}, {"c"}}
+
+	// the enumeration breaks off at page 2, after saving pages 0 and 1
+	if _, err := FirmList(&pagedSource{pages: pages, fail: 2}, cursor, nil); err != errPage {
+		t.Fatalf("got error %v, want %v", err, errPage)
+	}
+
+	var log bytes.Buffer
+	src := &pagedSource{pages: pages}
+	list, err := FirmList(src, cursor, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := links(list); got != "a,b,c" {
+		t.Errorf("got %s, want a,b,c", got)
+	}
+	if want := []int{2, 3}; !reflect.DeepEqual(src.requested, want) {
+		t.Errorf("requested pages %v, want %v", src.requested, want)
+	}
+	if !strings.Contains(log.String(), "resuming firm list at page 2 (2 firms)") {
+		t.Errorf("logged %q", log.String())
+	}
+	if _, err := os.Stat(cursor); !os.IsNotExist(err) {
+		t.Error("cursor left behind after a complete list")
+	}
+}
+
+func TestFirmListUnreadableCursor(t *testing.T) {
+	cursor := filepath.Join(t.TempDir(), "list.cursor")
+	os.WriteFile(cursor, []byte(`{"page": 5, "perma`), 0644)
+
+	var log bytes.Buffer
+	src := &pagedSource{pages: [][]string{{"a"}}}
+	list, err := FirmList(src, cursor, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links(list) != "a" || src.requested[0] != 0 {
+		t.Errorf("got %s from pages %v, want a from page 0", links(list), src.requested)
+	}
+	if !strings.Contains(log.String(), "ignoring unreadable list cursor") {
+		t.Errorf("logged %q", log.String())
+	}
+}
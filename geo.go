@@ -0,0 +1,164 @@
+package fundhawk
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Office is a location of a firm or company as the v1 API returns it. The
+// coordinates are nil if they are not known.
+type Office struct {
+	Description string   `json:"description"`
+	City        string   `json:"city"`
+	State       string   `json:"state_code"`
+	Country     string   `json:"country_code"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+}
+
+// Metro is the display name of the office's city, e.g. "Boston, MA".
+func (o Office) Metro() string {
+	if o.City == "" {
+		return ""
+	}
+	if o.State != "" {
+		return o.City + ", " + o.State
+	}
+	if o.Country != "" {
+		return o.City + ", " + o.Country
+	}
+	return o.City
+}
+
+func (o Office) located() bool {
+	return o.Latitude != nil && o.Longitude != nil && (*o.Latitude != 0 || *o.Longitude != 0)
+}
+
+// near reports whether two offices are in the same metro area: within
+// localRadius of each other if both have coordinates, otherwise in the same
+// city of the same country.
+func (o Office) near(p Office) bool {
+	if o.located() && p.located() {
+		return distance(o, p) <= localRadius
+	}
+	return o.City != "" && strings.EqualFold(o.City, p.City) && strings.EqualFold(o.Country, p.Country)
+}
+
+const (
+	// localRadius is how far in km a company may be from one of the firm's
+	// offices to count as a local investment.
+	localRadius = 80
+	earthRadius = 6371
+
+	topCountries = 8
+	topMetros    = 10
+
+	// MapWidth and MapHeight are the size of the dot map on the firm page,
+	// an equirectangular projection of the whole world.
+	MapWidth  = 720
+	MapHeight = 360
+)
+
+// distance is the great circle distance between two located offices in km.
+func distance(a, b Office) float64 {
+	rad := math.Pi / 180
+	lat1, lat2 := *a.Latitude*rad, *b.Latitude*rad
+	dlat, dlon := lat2-lat1, (*b.Longitude-*a.Longitude)*rad
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Headquarters is the company's first listed office, which CrunchBase lists
+// as the headquarters.
+func (p *CompanyProfile) Headquarters() *Office {
+	if p == nil || len(p.Offices) == 0 {
+		return nil
+	}
+	return &p.Offices[0]
+}
+
+// MapDot is a portfolio company on the firm's dot map, in SVG coordinates.
+type MapDot struct {
+	X, Y   float64
+	Radius float64
+	Label  string
+}
+
+// project maps a located office onto the MapWidth by MapHeight dot map.
+func project(o Office) (x, y float64) {
+	return projectPoint(*o.Longitude, *o.Latitude)
+}
+
+type mapDots []MapDot
+
+func (m mapDots) Len() int           { return len(m) }
+func (m mapDots) Less(i, j int) bool { return m[i].Radius > m[j].Radius }
+func (m mapDots) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+
+// calculateGeography computes where each firm invests: the share of its
+// investments in each country and metro area, how many of them are local to
+// one of its own offices and the dots of its portfolio map. Companies
+// without a known headquarters are left out.
+func (d *Dataset) calculateGeography() {
+	for _, vc := range d.VCs {
+		countries := make(map[string]int64)
+		metros := make(map[string]int64)
+		var located, local int
+		vc.MapDots = make([]MapDot, 0)
+
+		for company, rounds := range vc.RoundsByCompany {
+			hq := d.Companies[company.Permalink].Profile.Headquarters()
+			if hq == nil || (hq.City == "" && hq.Country == "") {
+				continue
+			}
+			located += int(rounds)
+			if hq.Country != "" {
+				countries[strings.ToUpper(hq.Country)] += rounds
+			}
+			if m := hq.Metro(); m != "" {
+				metros[m] += rounds
+			}
+			for _, o := range vc.Offices {
+				if hq.near(o) {
+					local += int(rounds)
+					break
+				}
+			}
+			if hq.located() {
+				x, y := project(*hq)
+				r := RoundFloat(2+2*math.Sqrt(float64(rounds)), 1)
+				vc.MapDots = append(vc.MapDots, MapDot{x, y, r, company.Name})
+			}
+		}
+		sort.Sort(mapDots(vc.MapDots))
+
+		vc.CountryDist = shareCounts(topCounts(countries, topCountries, othersBucket), located)
+		vc.MetroDist = shareCounts(topCounts(metros, topMetros, othersBucket), located)
+		vc.LocatedInvestments = located
+		if len(vc.Offices) > 0 {
+			vc.LocalRate = Percentage(located, local)
+		}
+	}
+}
+
+// RemoteRate is the percentage of located investments that are not local to
+// one of the firm's offices.
+func (vc *VC) RemoteRate() int64 {
+	if vc.LocatedInvestments == 0 {
+		return 0
+	}
+	return 100 - vc.LocalRate
+}
+
+// shareCounts turns the counts of b into percentages of total.
+func shareCounts(b BucketedInts, total int) BucketedInts {
+	b.Max = 0
+	for i := range b.Buckets {
+		b.Buckets[i].Count = Percentage(total, int(b.Buckets[i].Count))
+		if b.Buckets[i].Count > b.Max {
+			b.Max = b.Buckets[i].Count
+		}
+	}
+	return b
+}
@@ -0,0 +1,218 @@
+package fundhawk
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Graph is the coinvestment network: a node per firm and an undirected edge
+// between every two firms that took part in the same round, weighted by the
+// number of such rounds from the Dataset's MinYear to MaxYear.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Investments int    `json:"investments"`
+	Companies   int    `json:"companies"`
+}
+
+// GraphEdge is a coinvestment relationship. Source sorts before Target.
+type GraphEdge struct {
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	Weight    int    `json:"weight"`
+	FirstYear int    `json:"first_year"`
+	LastYear  int    `json:"last_year"`
+}
+
+type graphEdges []GraphEdge
+
+func (g graphEdges) Len() int      { return len(g) }
+func (g graphEdges) Swap(i, j int) { g[i], g[j] = g[j], g[i] }
+func (g graphEdges) Less(i, j int) bool {
+	if g[i].Source != g[j].Source {
+		return g[i].Source < g[j].Source
+	}
+	return g[i].Target < g[j].Target
+}
+
+// Graph returns the coinvestment network of the calculated dataset, built
+// from each firm's Partners.
+func (d *Dataset) Graph() *Graph {
+	g := &Graph{Nodes: make([]GraphNode, 0, len(d.VCs)), Edges: make([]GraphEdge, 0)}
+
+	links := make([]string, 0, len(d.VCs))
+	for p := range d.VCs {
+		links = append(links, p)
+	}
+	sort.Strings(links)
+
+	for _, link := range links {
+		vc := d.VCs[link]
+		g.Nodes = append(g.Nodes, GraphNode{link, vc.Name, len(vc.Investments), vc.TotalCompanies})
+		for v, p := range vc.Partners {
+			if v.Permalink <= link {
+				continue
+			}
+			g.Edges = append(g.Edges, GraphEdge{link, v.Permalink, p.Rounds, p.FirstYear, p.LastYear})
+		}
+	}
+	sort.Sort(graphEdges(g.Edges))
+	return g
+}
+
+// WriteJSON writes the graph as a list of nodes and a list of edges.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(g)
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	NS      string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLItem `xml:"node"`
+		Edges       []graphMLItem `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLItem struct {
+	ID     string        `xml:"id,attr,omitempty"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph in the GraphML format read by Gephi,
+// networkx and yEd.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{NS: "http://graphml.graphdrawing.org/xmlns"}
+	doc.Keys = []graphMLKey{
+		{"label", "node", "label", "string"},
+		{"investments", "node", "investments", "int"},
+		{"companies", "node", "companies", "int"},
+		{"weight", "edge", "weight", "int"},
+		{"first_year", "edge", "first_year", "int"},
+		{"last_year", "edge", "last_year", "int"},
+	}
+	doc.Graph.ID = "coinvestors"
+	doc.Graph.EdgeDefault = "undirected"
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLItem{ID: n.ID, Data: []graphMLData{
+			{"label", n.Label},
+			{"investments", strconv.Itoa(n.Investments)},
+			{"companies", strconv.Itoa(n.Companies)},
+		}})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLItem{Source: e.Source, Target: e.Target, Data: []graphMLData{
+			{"weight", strconv.Itoa(e.Weight)},
+			{"first_year", strconv.Itoa(e.FirstYear)},
+			{"last_year", strconv.Itoa(e.LastYear)},
+		}})
+	}
+	return writeXML(w, doc)
+}
+
+type gexf struct {
+	XMLName xml.Name `xml:"gexf"`
+	NS      string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   struct {
+		Mode       string         `xml:"mode,attr"`
+		EdgeType   string         `xml:"defaultedgetype,attr"`
+		TimeFormat string         `xml:"timeformat,attr"`
+		NodeAttrs  gexfAttributes `xml:"attributes"`
+		Nodes      []gexfNode     `xml:"nodes>node"`
+		Edges      []gexfEdge     `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+type gexfAttributes struct {
+	Class string          `xml:"class,attr"`
+	Attrs []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID     string          `xml:"id,attr"`
+	Label  string          `xml:"label,attr"`
+	Values []gexfAttrValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttrValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     string  `xml:"id,attr"`
+	Source string  `xml:"source,attr"`
+	Target string  `xml:"target,attr"`
+	Weight float64 `xml:"weight,attr"`
+	Start  int     `xml:"start,attr,omitempty"`
+	End    int     `xml:"end,attr,omitempty"`
+}
+
+// WriteGEXF writes the graph in Gephi's GEXF format. Edges span the years
+// from the first to the last coinvestment, so the graph can be played back
+// on Gephi's timeline.
+func (g *Graph) WriteGEXF(w io.Writer) error {
+	doc := gexf{NS: "http://www.gexf.net/1.2draft", Version: "1.2"}
+	doc.Graph.Mode = "dynamic"
+	doc.Graph.EdgeType = "undirected"
+	doc.Graph.TimeFormat = "integer"
+	doc.Graph.NodeAttrs = gexfAttributes{"node", []gexfAttribute{
+		{"investments", "investments", "integer"},
+		{"companies", "companies", "integer"},
+	}}
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{n.ID, n.Label, []gexfAttrValue{
+			{"investments", strconv.Itoa(n.Investments)},
+			{"companies", strconv.Itoa(n.Companies)},
+		}})
+	}
+	for i, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{strconv.Itoa(i), e.Source, e.Target, float64(e.Weight), e.FirstYear, e.LastYear})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,125 @@
+package fundhawk
+
+import (
+	"sort"
+	"strings"
+)
+
+// calculateLeads picks the lead investor of every company round and computes
+// each firm's lead metrics. A lead flagged by the source wins; otherwise the
+// lead is inferred as the firm that had joined the most rounds of the same
+// series in earlier years, then the firm that first appeared in the
+// company's history. A firm's lead rate is the share of the company rounds
+// it joined that it led.
+func (d *Dataset) calculateLeads() {
+	// years in which each firm joined a round of each series
+	stageYears := make(map[*VC]map[string][]int)
+	for _, c := range d.Companies {
+		for _, r := range c.Rounds {
+			for _, vc := range r.VCs {
+				if stageYears[vc] == nil {
+					stageYears[vc] = make(map[string][]int)
+				}
+				stageYears[vc][r.Round.Code] = append(stageYears[vc][r.Round.Code], roundYear(r.Round))
+			}
+		}
+	}
+	for _, codes := range stageYears {
+		for _, years := range codes {
+			sort.Ints(years)
+		}
+	}
+	prior := func(vc *VC, r Round) int {
+		return sort.SearchInts(stageYears[vc][r.Code], roundYear(r))
+	}
+
+	for _, c := range d.Companies {
+		firstSeen := make(map[*VC]int)
+		for i, r := range c.Rounds {
+			for _, vc := range r.VCs {
+				if _, ok := firstSeen[vc]; !ok {
+					firstSeen[vc] = i
+				}
+			}
+		}
+
+		for i := range c.Rounds {
+			r := &c.Rounds[i]
+			candidates := r.VCs
+			explicit := make([]*VC, 0)
+			for _, vc := range r.VCs {
+				if d.leadFlags[r.ID][vc] {
+					explicit = append(explicit, vc)
+				}
+			}
+			if len(explicit) > 0 {
+				candidates = explicit
+			}
+			r.LeadInferred = len(explicit) == 0
+
+			for _, vc := range candidates {
+				if r.Lead == nil {
+					r.Lead = vc
+					continue
+				}
+				pv, pl := prior(vc, r.Round), prior(r.Lead, r.Round)
+				if pv > pl || pv == pl && firstSeen[vc] < firstSeen[r.Lead] ||
+					pv == pl && firstSeen[vc] == firstSeen[r.Lead] && vc.Permalink < r.Lead.Permalink {
+					r.Lead = vc
+				}
+			}
+		}
+	}
+
+	for _, vc := range d.VCs {
+		vc.RoundsLed, vc.RoundsJoined = 0, 0
+		joined := make(map[string]int64)
+		led := make(map[string]int64)
+		followers := make(map[*VC]*Partner)
+
+		for company := range vc.RoundsByCompany {
+			for _, r := range d.Companies[company.Permalink].Rounds {
+				if !r.Has(vc) {
+					continue
+				}
+				vc.RoundsJoined++
+				joined[r.Round.Code]++
+				if r.Lead != vc {
+					continue
+				}
+				vc.RoundsLed++
+				led[r.Round.Code]++
+				for _, v := range r.VCs {
+					if v == vc {
+						continue
+					}
+					if followers[v] == nil {
+						followers[v] = &Partner{VC: v}
+					}
+					followers[v].Rounds++
+				}
+			}
+		}
+
+		vc.LeadRate = Percentage(vc.RoundsJoined, vc.RoundsLed)
+		vc.LeadRateDist = BucketedInts{Buckets: make([]BucketedInt, 0, len(joined))}
+		for _, b := range RoundCodeBuckets {
+			n, ok := joined[strings.ToLower(b)]
+			if !ok {
+				continue
+			}
+			rate := RoundInt(float64(led[strings.ToLower(b)]) * 100 / float64(n))
+			if rate > vc.LeadRateDist.Max {
+				vc.LeadRateDist.Max = rate
+			}
+			vc.LeadRateDist.Buckets = append(vc.LeadRateDist.Buckets, BucketedInt{b, rate})
+		}
+
+		vc.Followers = make(PartnerList, 0, len(followers))
+		for _, p := range followers {
+			p.Percentage = Percentage(vc.RoundsLed, p.Rounds)
+			vc.Followers = append(vc.Followers, p)
+		}
+		sort.Sort(vc.Followers)
+	}
+}
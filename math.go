@@ -1,10 +1,12 @@
-package main
+package fundhawk
 
 import (
 	"math"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/jedchristiansen/fundhawk/chart"
 )
 
 func Sum(p []int64) (a int64) {
@@ -68,40 +70,22 @@ func PrettyRound(i float64) string {
 	return strconv.FormatFloat(float64(RoundInt(x))/10, 'f', -1, 64) + suffix
 }
 
+// Percentage returns n as a whole percentage of total, or 0 if total is 0.
+func Percentage(total int, n int) int64 {
+	if total == 0 {
+		return 0
+	}
+	return RoundInt(float64(n) * 100 / float64(total))
+}
+
 func Itof(i int64) float64 {
 	return float64(i)
 }
 
 // NiceNum finds a "nice" number approximately equal to x. Round the number if round is true, otherwise take the
-// ceiling.  Described on Graphics Gems pg. 63
+// ceiling. See chart.NiceNum.
 func NiceNum(x float64, round bool) float64 {
-	exp := int(math.Floor(math.Log10(x)))
-	f := x / math.Pow10(exp)
-
-	var nf int
-	if round {
-		if f < 1.5 {
-			nf = 1
-		} else if f < 3 {
-			nf = 2
-		} else if f < 7 {
-			nf = 5
-		} else {
-			nf = 10
-		}
-	} else {
-		if f <= 1 {
-			nf = 1
-		} else if f <= 2 {
-			nf = 2
-		} else if f <= 5 {
-			nf = 5
-		} else {
-			nf = 10
-		}
-	}
-
-	return float64(nf) * math.Pow10(exp)
+	return chart.NiceNum(x, round)
 }
 
 func RoundFloat(x float64, prec int) float64 {
@@ -126,36 +110,6 @@ func RoundInt(n float64) int64 {
 	return int64(RoundFloat(n, 0))
 }
 
-func BarHeight(max int64, n int64) int {
-	return int(RoundInt((100 / float64(max)) * float64(n)))
-}
-
-const labelHeight = 20
-
-func BarMarginPadding(n int) int {
-	if n > 99 {
-		return 0
-	}
-	if n < 20 {
-		return 100 - n - labelHeight
-	}
-	return 100 - n
-}
-
-func BarMarginHeight(n int) int {
-	if n < 20 {
-		return labelHeight
-	}
-	return 0
-}
-
-func BarMarginLabel(n int) bool {
-	if n >= 20 {
-		return true
-	}
-	return false
-}
-
 type ValueBucket struct {
 	Name string
 	Min  int64
@@ -197,6 +151,26 @@ func (buckets ValueBuckets) Aggregate(values []int64) BucketedInts {
 	return res
 }
 
+// Bucket returns the name of the bucket x falls in.
+func (buckets ValueBuckets) Bucket(x int64) string {
+	name := buckets[0].Name
+	for _, b := range buckets {
+		if x >= b.Min {
+			name = b.Name
+		}
+	}
+	return name
+}
+
+// Names returns the bucket names in order.
+func (buckets ValueBuckets) Names() []string {
+	names := make([]string, len(buckets))
+	for i, b := range buckets {
+		names[i] = b.Name
+	}
+	return names
+}
+
 func Buckets(names ...string) ValueBuckets {
 	b := make([]ValueBucket, len(names))
 
@@ -0,0 +1,137 @@
+package fundhawk
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const metaSuffix = ".meta"
+
+// mirrorMeta is stored next to every mirrored file so the next fetch of the
+// same entity can be made conditional. The content hash catches unchanged
+// responses from servers that send neither validator.
+type mirrorMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	SHA256       string `json:"sha256"`
+}
+
+// readMirrorMeta loads the metadata of a mirrored file. It fails if either the
+// file or its metadata is missing.
+func readMirrorMeta(file string, meta *mirrorMeta) error {
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+	f, err := os.Open(file + metaSuffix)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(meta)
+}
+
+// writeFileAtomic replaces the file at path with data. The data is written to
+// a temporary file in the same directory which is then renamed over path, so
+// an interrupted write never leaves a partial file behind.
+func writeFileAtomic(path string, data []byte) error {
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	f, err := ioutil.TempFile(filepath.Dir(path), tempPrefix+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// tempPrefix marks files that writeFileAtomic has not renamed into place yet.
+// Any left in the mirror are from an interrupted run.
+const tempPrefix = ".tmp-"
+
+// writeJSONFile atomically replaces the file at path with v encoded as JSON.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// VerifyMirror decodes every firm in the mirror at dir as a VC. Files that do
+// not parse, and temporary files left by interrupted runs, are moved under
+// dir/quarantine so the next offline run does not trip over them. It returns
+// the number of files quarantined, and writes every one with the reason to
+// log, which may be nil.
+func VerifyMirror(dir string, log io.Writer) (int, error) {
+	root := filepath.Join(dir, "financial-organization")
+	quarantine := filepath.Join(dir, "quarantine", "financial-organization")
+
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return 0, err
+	}
+
+	var checked, bad int
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasSuffix(name, metaSuffix) {
+			continue
+		}
+		checked++
+
+		path := filepath.Join(root, name)
+		err := errors.New("left over from an interrupted write")
+		if !strings.HasPrefix(name, tempPrefix) {
+			err = verifyFirmFile(path)
+		}
+		if err == nil {
+			continue
+		}
+
+		bad++
+		logf(log, "%s: %s\n", name, err)
+		os.MkdirAll(quarantine, os.ModePerm)
+		if err := os.Rename(path, filepath.Join(quarantine, name)); err != nil {
+			return bad, err
+		}
+		// without the file its validators would make the next fetch a 304
+		os.Rename(path+metaSuffix, filepath.Join(quarantine, name+metaSuffix))
+	}
+
+	logf(log, "%d firm files checked, %d quarantined in %s\n", checked, bad, quarantine)
+	return bad, nil
+}
+
+func verifyFirmFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	vc := &VC{}
+	dec := json.NewDecoder(f)
+	if err := dec.Decode(vc); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("trailing data after firm JSON")
+	}
+	if vc.Permalink == "" {
+		return errors.New("no permalink")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package fundhawk
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMirrorKeepsGoodCopy(t *testing.T) {
+	good := `{"name": "Acme VC", "permalink": "acme-vc"}`
+	body, etag := good, `"1"`
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer s.Close()
+
+	mirror := t.TempDir()
+	src := &HTTPSource{BaseURL: s.URL + "/", Mirror: mirror, Client: NewClient(0, 0, 0)}
+	if err := src.Firm("acme-vc", &VC{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the download breaks off halfway
+	body, etag = good[:len(good)/2], `"2"`
+	if err := src.Firm("acme-vc", &VC{}); err == nil {
+		t.Error("got no error for a truncated response")
+	}
+
+	dir := filepath.Join(mirror, "financial-organization")
+	if data, err := os.ReadFile(filepath.Join(dir, "acme-vc")); err != nil || string(data) != good {
+		t.Errorf("mirror holds %q, %v, want the first response", data, err)
+	}
+	var meta mirrorMeta
+	if err := readMirrorMeta(filepath.Join(dir, "acme-vc"), &meta); err != nil || meta.ETag != `"1"` {
+		t.Errorf("mirror meta is %+v, %v, want the first response's", meta, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), tempPrefix) {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestVerifyMirror(t *testing.T) {
+	mirror := t.TempDir()
+	dir := filepath.Join(mirror, "financial-organization")
+	os.MkdirAll(dir, os.ModePerm)
+	for name, body := range map[string]string{
+		"good":                  `{"name": "Good VC", "permalink": "good"}`,
+		"good.meta":             `{"sha256": "x"}`,
+		"broken":                `{"name": "Broken VC", "perma`,
+		"broken.meta":           `{"etag": "\"1\"", "sha256": "y"}`,
+		"anonymous":             `{"name": "No Permalink"}`,
+		"twice":                 `{"permalink": "twice"}{"permalink": "twice"}`,
+		tempPrefix + "good1234": `{"name": "Good`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var log bytes.Buffer
+	n, err := VerifyMirror(mirror, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("quarantined %d files, want 4", n)
+	}
+	if !strings.Contains(log.String(), "broken: ") || !strings.Contains(log.String(), "5 firm files checked, 4 quarantined") {
+		t.Errorf("logged %q", log.String())
+	}
+
+	quarantine := filepath.Join(mirror, "quarantine", "financial-organization")
+	for name, want := range map[string]string{
+		"good":                  dir,
+		"good.meta":             dir,
+		"broken":                quarantine,
+		"broken.meta":           quarantine,
+		"anonymous":             quarantine,
+		"twice":                 quarantine,
+		tempPrefix + "good1234": quarantine,
+	} {
+		if _, err := os.Stat(filepath.Join(want, name)); err != nil {
+			t.Errorf("%s is not in %s", name, want)
+		}
+	}
+}
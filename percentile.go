@@ -0,0 +1,151 @@
+package fundhawk
+
+import "sort"
+
+// The peer groups that Dataset.Peers can rank firms within: every firm,
+// firms with a median round share in the same RoundShareBuckets bucket, or
+// firms whose most frequent round is the same series.
+const (
+	PeersAll   = "all"
+	PeersSize  = "size"
+	PeersStage = "stage"
+)
+
+// MinPeers is the smallest peer group a firm is ranked in. Firms whose group
+// is smaller are ranked among all firms instead.
+const MinPeers = 10
+
+// PeerRank is where one of a firm's headline metrics stands among its peers.
+type PeerRank struct {
+	// Percentile is the percentage of peers with a lower value.
+	Percentile int64 `json:"percentile"`
+
+	// Peers describes the firms ranked against, e.g. "all firms".
+	Peers string `json:"peers"`
+}
+
+// peerMetrics are the headline metrics of the firm page that are ranked.
+var peerMetrics = []string{
+	"companies", "companies_per_year", "rounds", "rounds_per_year",
+	"raised", "round_size", "round_share", "months_to_next_round",
+	"graduation_rate", "follow_on_rate", "avg_rounds_held", "lead_rate",
+	"exit_rate", "years_to_exit", "local_rate",
+}
+
+// peerValue returns the firm's value of a metric in peerMetrics. ok is false
+// if the firm page does not show the metric, and the firm is left out of its
+// ranking.
+func (vc *VC) peerValue(metric string) (v float64, ok bool) {
+	switch metric {
+	case "companies":
+		return float64(vc.TotalCompanies), true
+	case "companies_per_year":
+		return Median(vc.YearCompanySet), len(vc.YearCompanySet) > 0
+	case "rounds":
+		return float64(len(vc.RoundSizes)), true
+	case "rounds_per_year":
+		return Median(vc.YearRoundSet), len(vc.YearRoundSet) > 0
+	case "raised":
+		w := vc.allTime()
+		return float64(Sum(w.RoundSizes)), len(w.RoundSizes) > 0
+	case "round_size":
+		w := vc.allTime()
+		return Median(w.RoundSizes), len(w.RoundSizes) > 0
+	case "round_share":
+		w := vc.allTime()
+		return Median(w.RoundShares), len(w.RoundShares) > 0
+	case "months_to_next_round":
+		return Median(vc.MonthsToNextRound), len(vc.MonthsToNextRound) > 0
+	case "graduation_rate":
+		return float64(vc.GraduationRate), vc.GraduationCohort > 0
+	case "follow_on_rate":
+		return float64(vc.FollowOnRate), len(vc.FollowOnDist.Buckets) > 0
+	case "avg_rounds_held":
+		return vc.AvgRoundsHeld, len(vc.FollowOnDist.Buckets) > 0
+	case "lead_rate":
+		return float64(vc.LeadRate), vc.RoundsJoined > 0
+	case "exit_rate":
+		return float64(Percentage(vc.TotalCompanies, vc.Exits)), vc.TotalCompanies > 0
+	case "years_to_exit":
+		return Median(vc.YearsToExit), len(vc.YearsToExit) > 0
+	case "local_rate":
+		return float64(vc.LocalRate), len(vc.Offices) > 0 && vc.LocatedInvestments > 0
+	}
+	return 0, false
+}
+
+// allTime returns the firm's all time window, which the firm page shows the
+// round size and share percentiles in.
+func (vc *VC) allTime() *WindowMetrics {
+	for _, w := range vc.Windows {
+		if w.Months == 0 {
+			return w
+		}
+	}
+	return &WindowMetrics{}
+}
+
+// peerGroup returns the name of the firm's group for peers, or "" if it has
+// none and is only ranked among all firms.
+func (vc *VC) peerGroup(peers string) string {
+	switch peers {
+	case PeersSize:
+		if len(vc.RoundShares) == 0 {
+			return ""
+		}
+		return "firms with a " + RoundShareBuckets.Bucket(RoundInt(Median(vc.RoundShares))) + " median check"
+	case PeersStage:
+		var top BucketedInt
+		for _, b := range vc.SeriesDist.Buckets {
+			if b.Count > top.Count {
+				top = b
+			}
+		}
+		if top.Count == 0 {
+			return ""
+		}
+		return "firms mostly in " + top.Name + " rounds"
+	}
+	return ""
+}
+
+// calculatePercentiles ranks every firm's headline metrics within its peer
+// group, as set by d.Peers. It needs the windows from calculateWindows.
+func (d *Dataset) calculatePercentiles() {
+	groups := make(map[*VC]string, len(d.VCs))
+	for _, vc := range d.VCs {
+		vc.PeerRanks = make(map[string]*PeerRank)
+		groups[vc] = vc.peerGroup(d.Peers)
+	}
+
+	for _, m := range peerMetrics {
+		values := make(map[*VC]float64)
+		byGroup := make(map[string][]float64)
+		for _, vc := range d.VCs {
+			if v, ok := vc.peerValue(m); ok {
+				values[vc] = v
+				byGroup[""] = append(byGroup[""], v)
+				if g := groups[vc]; g != "" {
+					byGroup[g] = append(byGroup[g], v)
+				}
+			}
+		}
+		for _, vs := range byGroup {
+			sort.Float64s(vs)
+		}
+
+		for vc, v := range values {
+			g := groups[vc]
+			if len(byGroup[g]) < MinPeers {
+				g = ""
+			}
+			peers := byGroup[g]
+			lower := sort.SearchFloat64s(peers, v)
+			rank := &PeerRank{Percentile: Percentage(len(peers), lower), Peers: g}
+			if g == "" {
+				rank.Peers = "all firms"
+			}
+			vc.PeerRanks[m] = rank
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package fundhawk
+
+import (
+	"fmt"
+	"testing"
+)
+
+// peerDataset returns 12 firms f00 to f11, where firm i backed i+1
+// companies. The first ten write checks under 100k and the last two of
+// 1 - 3m. f00 joined no company rounds and none of the firms led or exited.
+func peerDataset(peers string) *Dataset {
+	d := NewDataset()
+	d.Peers = peers
+	for i := 0; i < 12; i++ {
+		vc := &VC{Permalink: fmt.Sprintf("f%02d", i), TotalCompanies: i + 1, RoundsJoined: i}
+		vc.RoundShares = IntSlice{50000}
+		if i >= 10 {
+			vc.RoundShares = IntSlice{2000000}
+		}
+		d.VCs[vc.Permalink] = vc
+	}
+	d.calculateWindows()
+	d.calculatePercentiles()
+	return d
+}
+
+func TestCalculatePercentilesAll(t *testing.T) {
+	d := peerDataset(PeersAll)
+	for i := 0; i < 12; i++ {
+		vc := d.VCs[fmt.Sprintf("f%02d", i)]
+		r := vc.PeerRanks["companies"]
+		if r == nil || r.Percentile != Percentage(12, i) || r.Peers != "all firms" {
+			t.Errorf("%s companies: got %+v, want %d among all firms", vc.Permalink, r, Percentage(12, i))
+		}
+		// a firm that led or exited nothing is still ranked, at the bottom
+		if r := vc.PeerRanks["exit_rate"]; r == nil || r.Percentile != 0 {
+			t.Errorf("%s exit_rate: got %+v, want 0", vc.Permalink, r)
+		}
+		if r, ok := vc.PeerRanks["lead_rate"]; ok != (i > 0) || ok && r.Percentile != 0 {
+			t.Errorf("%s lead_rate: got %+v", vc.Permalink, r)
+		}
+		// the check size is ranked as shown in the all time window
+		if r := vc.PeerRanks["round_share"]; r == nil || r.Percentile != Percentage(12, 10*(i/10)) {
+			t.Errorf("%s round_share: got %+v", vc.Permalink, r)
+		}
+		if _, ok := vc.PeerRanks["years_to_exit"]; ok {
+			t.Errorf("%s years_to_exit: ranked without exits", vc.Permalink)
+		}
+	}
+}
+
+func TestCalculatePercentilesSize(t *testing.T) {
+	d := peerDataset(PeersSize)
+	for i := 0; i < 12; i++ {
+		vc := d.VCs[fmt.Sprintf("f%02d", i)]
+		want := &PeerRank{Percentage(10, i), "firms with a <100k median check"}
+		if i >= 10 {
+			// the 1 - 3m group is smaller than MinPeers
+			want = &PeerRank{Percentage(12, i), "all firms"}
+		}
+		if r := vc.PeerRanks["companies"]; r == nil || *r != *want {
+			t.Errorf("%s companies: got %+v, want %+v", vc.Permalink, r, want)
+		}
+	}
+}
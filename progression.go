@@ -0,0 +1,94 @@
+package fundhawk
+
+import "time"
+
+var NextRoundBuckets = Buckets("<6", "6 - 12", "12 - 18", "18 - 24", "24 - 36", ">36")
+
+// graduationWindow is how soon after its seed round a company must raise a
+// Series A to count as graduated.
+const graduationWindow = 24
+
+// setDate fills in Date from the year, month and day fields. Missing months
+// and days count as the first of the year or month.
+func (r *Round) setDate() {
+	if r.Year == nil {
+		return
+	}
+	month, day := 1, 1
+	if r.Month != nil && *r.Month >= 1 && *r.Month <= 12 {
+		month = *r.Month
+	}
+	if r.Day != nil && *r.Day >= 1 && *r.Day <= 31 {
+		day = *r.Day
+	}
+	r.Date = time.Date(*r.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// Dated reports whether the round's date is known to the month.
+func (r Round) Dated() bool {
+	return r.Year != nil && r.Month != nil
+}
+
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
+
+// lastRoundDate is the date of the most recent round in the dataset up to
+// d.MaxYear, which stands for "now" so that a snapshot is measured against its
+// own data.
+func (d *Dataset) lastRoundDate() time.Time {
+	var last time.Time
+	for _, r := range d.Rounds {
+		if roundYear(r) <= d.MaxYear && r.Date.After(last) {
+			last = r.Date
+		}
+	}
+	return last
+}
+
+// calculateProgression computes how quickly each firm's portfolio companies
+// go on to raise: the months from every round the firm joined to the
+// company's next round, and the share of its seed companies that raised a
+// Series A within graduationWindow months of the first dated seed round the
+// firm joined. Companies whose seed round is too recent to have had the full
+// window are left out of the graduation rate.
+func (d *Dataset) calculateProgression() {
+	asOf := d.lastRoundDate()
+
+	for _, vc := range d.VCs {
+		vc.MonthsToNextRound = make(IntSlice, 0)
+		var cohort, graduated int
+
+		for company := range vc.RoundsByCompany {
+			rounds := d.Companies[company.Permalink].Rounds
+			seed := -1
+			for i, r := range rounds {
+				if !r.Has(vc) {
+					continue
+				}
+				if i+1 < len(rounds) && r.Round.Dated() && rounds[i+1].Round.Dated() {
+					vc.MonthsToNextRound = append(vc.MonthsToNextRound, int64(monthsBetween(r.Round.Date, rounds[i+1].Round.Date)))
+				}
+				if seed < 0 && r.Round.Code == "seed" && r.Round.Dated() {
+					seed = i
+				}
+			}
+
+			if seed < 0 || monthsBetween(rounds[seed].Round.Date, asOf) < graduationWindow {
+				continue
+			}
+			cohort++
+			for _, next := range rounds[seed+1:] {
+				if next.Round.Code == "a" && next.Round.Dated() && monthsBetween(rounds[seed].Round.Date, next.Round.Date) <= graduationWindow {
+					graduated++
+					break
+				}
+			}
+		}
+
+		vc.MonthsToNextRound.Sort()
+		vc.NextRoundDist = NextRoundBuckets.Aggregate(vc.MonthsToNextRound)
+		vc.GraduationCohort = cohort
+		vc.GraduationRate = Percentage(cohort, graduated)
+	}
+}
@@ -0,0 +1,427 @@
+package fundhawk
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	ttemplate "text/template"
+	"time"
+)
+
+// Output is where the generated site is written.
+type Output interface {
+	Put(path string, r io.Reader) error
+}
+
+// DirOutput writes the site to a local directory.
+type DirOutput string
+
+func (o DirOutput) Put(path string, r io.Reader) error {
+	path = filepath.Join(string(o), path)
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// Progress writes a running count of finished fetches or writes to Out, or
+// only counts them if Out is nil. A nil Progress counts nothing.
+type Progress struct {
+	Out io.Writer
+
+	done  int32
+	total int32
+}
+
+// Reset starts counting again towards total.
+func (p *Progress) Reset(total int) {
+	if p == nil {
+		return
+	}
+	atomic.StoreInt32(&p.done, 0)
+	atomic.StoreInt32(&p.total, int32(total))
+}
+
+func (p *Progress) Incr() {
+	if p == nil {
+		return
+	}
+	logf(p.Out, "\r%d/%d", atomic.AddInt32(&p.done, 1), atomic.LoadInt32(&p.total))
+}
+
+// Site renders a Dataset as the static Fundhawk site: a page per firm, the
+// search index, the sitemap and the fingerprinted assets they link to.
+type Site struct {
+	Out Output
+
+	// AssetOut receives the CSS and JS from the Assets directory, which the
+	// pages link to under AssetURL.
+	AssetOut Output
+	AssetURL string
+
+	Templates string
+	Assets    string
+	Workers   int
+	Progress  *Progress
+
+	assets map[string]string
+}
+
+// NewSite returns a Site writing pages to out and assets to assetOut, using
+// the templates and assets directories relative to the working directory.
+func NewSite(out, assetOut Output, assetURL string) *Site {
+	return &Site{
+		Out:       out,
+		AssetOut:  assetOut,
+		AssetURL:  assetURL,
+		Templates: "templates",
+		Assets:    "assets",
+		Workers:   40,
+	}
+}
+
+// Put writes a single file of the site.
+func (s *Site) Put(path string, r io.Reader) error {
+	s.Progress.Incr()
+	return s.Out.Put(path, r)
+}
+
+// Funcs returns the functions available to the site's templates.
+func (s *Site) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"first":        First,
+		"last":         Last,
+		"mean":         Mean,
+		"median":       Median,
+		"sum":          Sum,
+		"round":        Roundf,
+		"pround":       PrettyRound,
+		"itof":         Itof,
+		"barchart":     BarChart,
+		"histogram":    HistogramChart,
+		"yearchart":    YearChart,
+		"sectorchart":  SectorShiftChart,
+		"comparechart": ComparedChart,
+		"series":       RoundName,
+		"percent":      Percentage,
+		"fixed":        fixed,
+		"ordinal":      ordinal,
+		"worldmap":     World,
+		"asset":        s.AssetPath,
+		"timestamp":    htmlTimestamp,
+	}
+}
+
+// Render writes the whole site for d, which must have been calculated. A page
+// that fails does not stop the others, and the failures are returned once
+// the site is written.
+func (s *Site) Render(d *Dataset) error {
+	if err := s.WriteAssets(); err != nil {
+		return err
+	}
+
+	t, err := template.New("vc").Funcs(s.Funcs()).ParseFiles(
+		s.template("vc.html"),
+		s.template("company.html"),
+		s.template("index.html"),
+		s.template("connected.html"),
+		s.template("cluster.html"),
+		s.template("compare.html"),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.Progress.Reset(2*len(d.VCs) + len(d.Companies) + len(d.Clusters))
+	queue := make(chan func() error)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []string
+	for i := 0; i < s.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			for page := range queue {
+				if err := page(); err != nil {
+					mu.Lock()
+					failed = append(failed, err.Error())
+					mu.Unlock()
+				}
+			}
+			wg.Done()
+		}()
+	}
+	for _, vc := range d.VCs {
+		vc := vc
+		queue <- func() error { return s.renderFirm(t, vc) }
+		queue <- func() error { return s.renderFirmAPI(vc) }
+	}
+	for _, c := range d.Companies {
+		c := c
+		queue <- func() error { return s.renderCompany(t, c) }
+	}
+	for _, c := range d.Clusters {
+		c := c
+		queue <- func() error { return s.renderCluster(t, c) }
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, fn := range []func() error{
+		func() error { return s.execute(t, "index.html", d, "index.html") },
+		func() error { return s.execute(t, "connected.html", d, "connected.html") },
+		func() error { return s.execute(t, "compare.html", compareBuckets, "compare.html") },
+		func() error { return s.renderIndexJSON(d) },
+		func() error { return s.renderFirmList(d) },
+		func() error { return s.renderSitemap(d) },
+		func() error { return s.renderGraph(d) },
+		s.putTrackingGIF,
+	} {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%d pages failed to render:\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+func (s *Site) template(name string) string {
+	return filepath.Join(s.Templates, name)
+}
+
+// executor is an html or text template.
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// execute renders the named template with data to path in the site.
+func (s *Site) execute(t executor, name string, data interface{}, path string) error {
+	r, w := io.Pipe()
+	go func() {
+		if err := t.ExecuteTemplate(w, name, data); err != nil {
+			w.CloseWithError(fmt.Errorf("%s: %s", path, err))
+			return
+		}
+		w.Close()
+	}()
+
+	return s.Put(path, r)
+}
+
+func (s *Site) renderFirm(t *template.Template, vc *VC) error {
+	return s.execute(t, "vc.html", vc, "firms/"+vc.Permalink+".html")
+}
+
+func (s *Site) renderFirmAPI(vc *VC) error {
+	return s.write(APIFirmPath(vc.Permalink), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(vc.API())
+	})
+}
+
+func (s *Site) renderFirmList(d *Dataset) error {
+	for _, l := range d.APIFirmList() {
+		l := l
+		err := s.write(APIFirmListPath(l.Page), func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(l)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Site) renderCompany(t *template.Template, c *CompanyHistory) error {
+	return s.execute(t, "company.html", c, "companies/"+c.Company.Permalink+".html")
+}
+
+func (s *Site) renderCluster(t *template.Template, c *Cluster) error {
+	return s.execute(t, "cluster.html", c, "clusters/"+c.ID+".html")
+}
+
+func (s *Site) putTrackingGIF() error {
+	r, err := os.Open(filepath.Join(s.Assets, "s.gif"))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return s.Put("s.gif", r)
+}
+
+// SitemapSize is the most URLs one sitemap may list. Larger sites are split
+// into sitemaps/<n>.xml, which sitemap.xml lists as a sitemap index.
+const SitemapSize = 50000
+
+// sitemaps returns the paths of the site's pages, in sitemaps of at most
+// size paths.
+func sitemaps(d *Dataset, size int) [][]string {
+	firms := make([]string, 0, len(d.VCs))
+	for _, vc := range d.VCs {
+		firms = append(firms, "firms/"+vc.Permalink+".html")
+	}
+	clusters := make([]string, 0, len(d.Clusters))
+	for _, c := range d.Clusters {
+		clusters = append(clusters, "clusters/"+c.ID+".html")
+	}
+	companies := make([]string, 0, len(d.Companies))
+	for _, c := range d.Companies {
+		companies = append(companies, "companies/"+c.Company.Permalink+".html")
+	}
+
+	paths := []string{"connected.html", "compare.html"}
+	for _, group := range [][]string{firms, clusters, companies} {
+		sort.Strings(group)
+		paths = append(paths, group...)
+	}
+
+	maps := make([][]string, 0, (len(paths)+size-1)/size)
+	for len(paths) > size {
+		maps = append(maps, paths[:size])
+		paths = paths[size:]
+	}
+	return append(maps, paths)
+}
+
+func (s *Site) renderSitemap(d *Dataset) error {
+	t, err := ttemplate.ParseFiles(s.template("sitemap.xml"), s.template("sitemapindex.xml"))
+	if err != nil {
+		return err
+	}
+
+	index := make([]string, 0)
+	for i, paths := range sitemaps(d, SitemapSize) {
+		path := fmt.Sprintf("sitemaps/%d.xml", i+1)
+		if err := s.execute(t, "sitemap.xml", paths, path); err != nil {
+			return err
+		}
+		index = append(index, path)
+	}
+	if err := s.execute(t, "sitemapindex.xml", index, "sitemap.xml"); err != nil {
+		return err
+	}
+	return s.Put("robots.txt", strings.NewReader("Sitemap: http://fundhawk.com/sitemap.xml"))
+}
+
+// renderIndexJSON writes the search index and, for each firm's position in
+// it, the positions of the firms most similar to it.
+func (s *Site) renderIndexJSON(d *Dataset) error {
+	pos := make(map[string]int, len(d.VCs))
+	for i, e := range d.DataList {
+		if e[2] == "firms" {
+			pos[e[0]] = i
+		}
+	}
+	similar := make(map[int][]int, len(d.VCs))
+	for _, vc := range d.VCs {
+		ids := make([]int, 0, len(vc.Similar))
+		for _, f := range vc.Similar {
+			ids = append(ids, pos[f.VC.Permalink])
+		}
+		similar[pos[vc.Permalink]] = ids
+	}
+
+	return s.write("index.json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(map[string]interface{}{"a": d.DataList, "b": d.NamePrefixes, "c": similar})
+	})
+}
+
+// compareBuckets is the order of the buckets of the API distributions the
+// client-side compare page overlays.
+var compareBuckets = map[string][]string{
+	"series":      RoundCodeBuckets,
+	"round_size":  RoundSizeBuckets.Names(),
+	"round_share": RoundShareBuckets.Names(),
+}
+
+// RenderComparison writes a report comparing the given firms side by side,
+// as compare/<a>-vs-<b>.html and .json, and returns its path without the
+// extension. d must have been calculated.
+func (s *Site) RenderComparison(d *Dataset, permalinks []string) (string, error) {
+	c, err := d.Compare(permalinks)
+	if err != nil {
+		return "", err
+	}
+	if err := s.WriteAssets(); err != nil {
+		return "", err
+	}
+	t, err := template.New("comparison").Funcs(s.Funcs()).ParseFiles(s.template("comparison.html"))
+	if err != nil {
+		return "", err
+	}
+
+	path := "compare/" + strings.Join(permalinks, "-vs-")
+	if err := s.execute(t, "comparison.html", c, path+".html"); err != nil {
+		return "", err
+	}
+	return path, s.write(path+".json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(c)
+	})
+}
+
+// renderGraph exports the coinvestment network for analysis in other tools.
+func (s *Site) renderGraph(d *Dataset) error {
+	g := d.Graph()
+	for path, write := range map[string]func(io.Writer) error{
+		"graph/coinvestors.json":    g.WriteJSON,
+		"graph/coinvestors.graphml": g.WriteGraphML,
+		"graph/coinvestors.gexf":    g.WriteGEXF,
+	} {
+		if err := s.write(path, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write streams the output of fn to path in the site.
+func (s *Site) write(path string, fn func(io.Writer) error) error {
+	r, w := io.Pipe()
+	go func() {
+		if err := fn(w); err != nil {
+			w.CloseWithError(fmt.Errorf("%s: %s", path, err))
+			return
+		}
+		w.Close()
+	}()
+
+	return s.Put(path, r)
+}
+
+// fixed formats f with prec decimals.
+func fixed(prec int, f float64) string {
+	return strconv.FormatFloat(f, 'f', prec, 64)
+}
+
+// ordinal formats n as an English ordinal, e.g. 23rd.
+func ordinal(n int64) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.FormatInt(n, 10) + suffix
+}
+
+func htmlTimestamp() template.HTML {
+	return template.HTML("<!-- Generated at " + time.Now().Format(time.RFC3339Nano) + " -->")
+}
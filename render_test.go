@@ -0,0 +1,25 @@
+package fundhawk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSitemaps(t *testing.T) {
+	d := analyticsDataset()
+	maps := sitemaps(d, 5)
+
+	want := [][]string{
+		{"connected.html", "compare.html", "firms/alpha.html", "firms/beta.html", "firms/delta.html"},
+		{"firms/epsilon.html", "firms/gamma.html", "clusters/alpha.html", "clusters/delta.html", "companies/gadget.html"},
+		{"companies/sprocket.html", "companies/widget.html"},
+	}
+	if !reflect.DeepEqual(maps, want) {
+		t.Errorf("got %v, want %v", maps, want)
+	}
+
+	// a site that fits in one sitemap still gets an index
+	if maps := sitemaps(d, SitemapSize); len(maps) != 1 || len(maps[0]) != 12 {
+		t.Errorf("got %d sitemaps, want one of 12 pages", len(maps))
+	}
+}
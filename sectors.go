@@ -0,0 +1,161 @@
+package fundhawk
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// topSectors is how many sectors a firm's sector charts show; the rest
+	// are summed up as othersBucket.
+	topSectors = 8
+	topTags    = 15
+
+	// othersBucket names the bucket topCounts sums the smaller counts into.
+	// It must not collide with a real name, such as CrunchBase's "other"
+	// category.
+	othersBucket = "All others"
+)
+
+// Tags splits the company's comma separated tag list.
+func (p *CompanyProfile) Tags() []string {
+	if p == nil {
+		return nil
+	}
+	tags := make([]string, 0)
+	for _, t := range strings.Split(p.TagList, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// Sector is the display name of the company's CrunchBase category, or ""
+// if it is not known.
+func (p *CompanyProfile) Sector() string {
+	if p == nil || p.Category == "" {
+		return ""
+	}
+	return strings.Title(strings.Replace(p.Category, "_", " ", -1))
+}
+
+// SectorShift is the share of a firm's new companies in each of its top
+// sectors, year by year.
+type SectorShift struct {
+	Sectors []string
+	Years   []SectorYear
+}
+
+type SectorYear struct {
+	Year      int
+	Companies int
+	Shares    []int64
+}
+
+// countedNames sorts names by their count, largest first.
+type countedNames struct {
+	names  []string
+	counts map[string]int64
+}
+
+func (c countedNames) Len() int      { return len(c.names) }
+func (c countedNames) Swap(i, j int) { c.names[i], c.names[j] = c.names[j], c.names[i] }
+func (c countedNames) Less(i, j int) bool {
+	ci, cj := c.counts[c.names[i]], c.counts[c.names[j]]
+	if ci != cj {
+		return ci > cj
+	}
+	return c.names[i] < c.names[j]
+}
+
+// topCounts returns counts as BucketedInts, largest first, keeping the n
+// largest and summing the rest into other if it is set.
+func topCounts(counts map[string]int64, n int, other string) BucketedInts {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Sort(countedNames{names, counts})
+
+	res := BucketedInts{Buckets: make([]BucketedInt, 0, n+1)}
+	var rest int64
+	for i, name := range names {
+		if i >= n {
+			rest += counts[name]
+			continue
+		}
+		res.Buckets = append(res.Buckets, BucketedInt{name, counts[name]})
+	}
+	if rest > 0 && other != "" {
+		res.Buckets = append(res.Buckets, BucketedInt{other, rest})
+	}
+	for _, b := range res.Buckets {
+		if b.Count > res.Max {
+			res.Max = b.Count
+		}
+	}
+	return res
+}
+
+// calculateSectors computes each firm's sector focus and top tags from the
+// profiles of its portfolio companies, and how its sector mix shifted from
+// year to year, by the year the firm first invested in each company.
+func (d *Dataset) calculateSectors() {
+	for _, vc := range d.VCs {
+		sectors := make(map[string]int64)
+		tags := make(map[string]int64)
+		byYear := make(map[int]map[string]int64)
+		companiesByYear := make(map[int]int)
+
+		for company := range vc.RoundsByCompany {
+			c := d.Companies[company.Permalink]
+			for _, t := range c.Profile.Tags() {
+				tags[t]++
+			}
+			sector := c.Profile.Sector()
+			if sector == "" {
+				continue
+			}
+			sectors[sector]++
+
+			for _, r := range c.Rounds {
+				if !r.Has(vc) {
+					continue
+				}
+				if y := roundYear(r.Round); d.inYears(y) {
+					if byYear[y] == nil {
+						byYear[y] = make(map[string]int64)
+					}
+					byYear[y][sector]++
+					companiesByYear[y]++
+				}
+				break
+			}
+		}
+
+		vc.sectors = sectors
+		vc.SectorDist = topCounts(sectors, topSectors, othersBucket)
+		vc.TagDist = topCounts(tags, topTags, "")
+
+		shift := SectorShift{Sectors: make([]string, 0, topSectors)}
+		for _, b := range vc.SectorDist.Buckets {
+			if b.Name != othersBucket {
+				shift.Sectors = append(shift.Sectors, b.Name)
+			}
+		}
+		years := make([]int, 0, len(byYear))
+		for y := range byYear {
+			years = append(years, y)
+		}
+		sort.Ints(years)
+		for _, y := range years {
+			sy := SectorYear{Year: y, Companies: companiesByYear[y], Shares: make([]int64, len(shift.Sectors))}
+			for i, s := range shift.Sectors {
+				sy.Shares[i] = Percentage(sy.Companies, int(byYear[y][s]))
+			}
+			shift.Years = append(shift.Years, sy)
+		}
+		vc.SectorShift = shift
+	}
+}
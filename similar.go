@@ -0,0 +1,194 @@
+package fundhawk
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// SimilarFirm is a firm that invests like another one.
+type SimilarFirm struct {
+	VC *VC
+
+	// Score is the similarity as a percentage, and SharedCompanies the
+	// number of portfolio companies both firms invested in.
+	Score           int64
+	SharedCompanies int
+}
+
+type similarFirms []SimilarFirm
+
+func (s similarFirms) Len() int      { return len(s) }
+func (s similarFirms) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s similarFirms) Less(i, j int) bool {
+	if s[i].Score != s[j].Score {
+		return s[i].Score > s[j].Score
+	}
+	return s[i].VC.Permalink < s[j].VC.Permalink
+}
+
+// similarHeap keeps the best similarFirmCount firms found so far, with the
+// least similar one on top to be replaced.
+type similarHeap struct{ similarFirms }
+
+func (h similarHeap) Less(i, j int) bool  { return h.similarFirms.Less(j, i) }
+func (h *similarHeap) Push(x interface{}) { h.similarFirms = append(h.similarFirms, x.(SimilarFirm)) }
+func (h *similarHeap) Pop() interface{} {
+	s := h.similarFirms
+	x := s[len(s)-1]
+	h.similarFirms = s[:len(s)-1]
+	return x
+}
+
+// add offers a firm to the heap, dropping the least similar one once it
+// holds similarFirmCount.
+func (h *similarHeap) add(f SimilarFirm) {
+	if h.Len() < similarFirmCount {
+		heap.Push(h, f)
+	} else if (similarFirms{f, h.similarFirms[0]}).Less(0, 1) {
+		h.similarFirms[0] = f
+		heap.Fix(h, 0)
+	}
+}
+
+const similarFirmCount = 10
+
+// How much each signal counts towards the similarity of two firms. A signal
+// either firm has no data for is left out and the others weighted up.
+const (
+	stageWeight     = 0.3
+	sizeWeight      = 0.2
+	sectorWeight    = 0.2
+	portfolioWeight = 0.3
+)
+
+// vector turns a distribution into a vector keyed by bucket name.
+func vector(b BucketedInts) map[string]float64 {
+	v := make(map[string]float64, len(b.Buckets))
+	for _, x := range b.Buckets {
+		if x.Count > 0 {
+			v[x.Name] = float64(x.Count)
+		}
+	}
+	return v
+}
+
+func countVector(counts map[string]int64) map[string]float64 {
+	v := make(map[string]float64, len(counts))
+	for k, c := range counts {
+		v[k] = float64(c)
+	}
+	return v
+}
+
+func norm(v map[string]float64) float64 {
+	var n float64
+	for _, x := range v {
+		n += x * x
+	}
+	return math.Sqrt(n)
+}
+
+// cosine is the cosine similarity of two vectors with the given norms.
+func cosine(a, b map[string]float64, na, nb float64) float64 {
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for k, x := range a {
+		dot += x * b[k]
+	}
+	return dot / (na * nb)
+}
+
+// firmProfile holds the vectors a firm is compared by.
+type firmProfile struct {
+	stage, size, sector    map[string]float64
+	nStage, nSize, nSector float64
+}
+
+func newFirmProfile(vc *VC) *firmProfile {
+	p := &firmProfile{
+		stage:  vector(vc.SeriesDist),
+		size:   vector(vc.ShareDist),
+		sector: countVector(vc.sectors),
+	}
+	p.nStage, p.nSize, p.nSector = norm(p.stage), norm(p.size), norm(p.sector)
+	return p
+}
+
+// similarity scores two firms from 0 to 1 by the cosine similarity of their
+// stage, check size and sector mixes and the Jaccard index of their
+// portfolios.
+func similarity(a, b *VC, pa, pb *firmProfile, shared int) float64 {
+	var score, weight float64
+	add := func(w, s float64, ok bool) {
+		if ok {
+			score += w * s
+			weight += w
+		}
+	}
+	add(stageWeight, cosine(pa.stage, pb.stage, pa.nStage, pb.nStage), pa.nStage > 0 && pb.nStage > 0)
+	add(sizeWeight, cosine(pa.size, pb.size, pa.nSize, pb.nSize), pa.nSize > 0 && pb.nSize > 0)
+	add(sectorWeight, cosine(pa.sector, pb.sector, pa.nSector, pb.nSector), pa.nSector > 0 && pb.nSector > 0)
+	union := len(a.RoundsByCompany) + len(b.RoundsByCompany) - shared
+	add(portfolioWeight, float64(shared)/float64(union), union > 0)
+	if weight == 0 {
+		return 0
+	}
+	return score / weight
+}
+
+// calculateSimilar finds the firms most like each firm. Only firms sharing a
+// portfolio company with it, its coinvestors among them, are scored, which
+// keeps the work to the size of the portfolios rather than every two firms.
+// It needs the sector counts from calculateSectors.
+func (d *Dataset) calculateSimilar() {
+	vcs := make([]*VC, 0, len(d.VCs))
+	for _, vc := range d.VCs {
+		vcs = append(vcs, vc)
+	}
+	sort.Sort(vcsByPermalink(vcs))
+
+	profiles := make(map[*VC]*firmProfile, len(vcs))
+	for _, vc := range vcs {
+		profiles[vc] = newFirmProfile(vc)
+	}
+
+	// count shared portfolio companies through the companies rather than
+	// comparing every two portfolios
+	shared := make(map[*VC]map[*VC]int, len(vcs))
+	for _, c := range d.Companies {
+		backers := make(map[*VC]bool)
+		for _, r := range c.Rounds {
+			for _, vc := range r.VCs {
+				backers[vc] = true
+			}
+		}
+		for a := range backers {
+			if shared[a] == nil {
+				shared[a] = make(map[*VC]int)
+			}
+			for b := range backers {
+				if a != b {
+					shared[a][b]++
+				}
+			}
+		}
+	}
+
+	for _, vc := range vcs {
+		h := &similarHeap{make(similarFirms, 0, similarFirmCount)}
+		for other, n := range shared[vc] {
+			s := similarity(vc, other, profiles[vc], profiles[other], n)
+			if score := RoundInt(s * 100); score > 0 {
+				h.add(SimilarFirm{other, score, n})
+			}
+		}
+		sort.Sort(h.similarFirms)
+		vc.Similar = h.similarFirms
+	}
+}
@@ -0,0 +1,244 @@
+package fundhawk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source is a backend that CrunchBase entities are loaded from. Whatever the
+// storage, entities are decoded into the same structures as the v1 API JSON.
+type Source interface {
+	// ListFirms decodes one page of the financial organization list.
+	ListFirms(page int, list *Permalinks) error
+	Firm(permalink string, vc *VC) error
+	Company(permalink string, c *CompanyProfile) error
+	Round(id string, r *Round) error
+}
+
+// SourceConfig holds the settings a registered backend is created with.
+type SourceConfig struct {
+	// Path is the data directory: the mirror read by the file source and
+	// written by the crunchbase source if Save is set.
+	Path string
+
+	Key           string
+	Client        *Client
+	Progress      *Progress
+	Save          bool
+	SkipUnchanged bool
+}
+
+var sources = map[string]func(SourceConfig) Source{
+	"crunchbase": func(c SourceConfig) Source {
+		s := &HTTPSource{BaseURL: BaseURL, Key: c.Key, Client: c.Client, Progress: c.Progress}
+		if c.Save {
+			s.Mirror = c.Path
+			s.SkipUnchanged = c.SkipUnchanged
+		}
+		return s
+	},
+	"file": func(c SourceConfig) Source { return FileSource(c.Path) },
+}
+
+// RegisterSource makes a backend available to NewSource under name.
+func RegisterSource(name string, fn func(SourceConfig) Source) {
+	sources[name] = fn
+}
+
+// Sources returns the names of all registered backends.
+func Sources() []string {
+	names := make([]string, 0, len(sources))
+	for n := range sources {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSource returns the backend registered under name.
+func NewSource(name string, c SourceConfig) (Source, error) {
+	fn, ok := sources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q (available: %s)", name, strings.Join(Sources(), ", "))
+	}
+	if c.Client == nil {
+		c.Client = NewClient(0, 0, 0)
+	}
+	return fn(c), nil
+}
+
+// HTTPSource fetches entities from the CrunchBase v1 API through Client. If
+// Mirror is set, every response is also saved there in the layout FileSource
+// reads, and single entities already in the mirror are requested
+// conditionally. Entities that have not changed are decoded from the mirror,
+// or skipped with ErrNotModified if SkipUnchanged is set. List pages are
+// always fetched in full.
+type HTTPSource struct {
+	BaseURL       string
+	Key           string
+	Mirror        string
+	SkipUnchanged bool
+	Client        *Client
+	Progress      *Progress
+}
+
+func (s *HTTPSource) ListFirms(page int, list *Permalinks) error {
+	return s.get("financial-organizations", page, listFile(page), false, list)
+}
+
+func (s *HTTPSource) Firm(permalink string, vc *VC) error {
+	return s.Get("financial-organization/"+permalink, 0, vc)
+}
+
+func (s *HTTPSource) Company(permalink string, c *CompanyProfile) error {
+	return s.Get("company/"+permalink, 0, c)
+}
+
+func (s *HTTPSource) Round(id string, r *Round) error {
+	return s.Get("funding-round/"+id, 0, r)
+}
+
+func (s *HTTPSource) url(path string) string {
+	return s.BaseURL + path + ".js?api_key=" + s.Key
+}
+
+// Get fetches the single entity at path, mirrored to the same path.
+func (s *HTTPSource) Get(path string, page int, data interface{}) error {
+	return s.get(path, page, path, page == 0, data)
+}
+
+// listFile is the mirror path of a page of the firm list.
+func listFile(page int) string {
+	if page == 0 {
+		return "financial-organizations"
+	}
+	return fmt.Sprintf("financial-organizations-%d", page)
+}
+
+// get fetches path and mirrors it to name. Only single entities are fetched
+// conditionally: a list page must never return ErrNotModified, which would
+// end the enumeration of a refresh.
+func (s *HTTPSource) get(path string, page int, name string, conditional bool, data interface{}) error {
+	uri := s.url(path)
+	if page > 0 {
+		uri += fmt.Sprintf("&page=%d", page)
+	}
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return err
+	}
+
+	var file string
+	var meta mirrorMeta
+	if s.Mirror != "" {
+		file = s.Mirror + "/" + name
+		if conditional && readMirrorMeta(file, &meta) == nil {
+			if meta.ETag != "" {
+				req.Header.Set("If-None-Match", meta.ETag)
+			}
+			if meta.LastModified != "" {
+				req.Header.Set("If-Modified-Since", meta.LastModified)
+			}
+		}
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 && res.StatusCode != 304 {
+		return fmt.Errorf("get %s - incorrect response code received - %d", uri, res.StatusCode)
+	}
+	s.Progress.Incr()
+
+	if res.StatusCode == 304 {
+		if s.SkipUnchanged {
+			return ErrNotModified
+		}
+		return FileSource(s.Mirror).Get(path, 0, data)
+	}
+
+	if s.Mirror == "" {
+		return json.NewDecoder(res.Body).Decode(data)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	unchanged := conditional && hash == meta.SHA256
+	if unchanged && s.SkipUnchanged {
+		return ErrNotModified
+	}
+
+	// a truncated or otherwise broken response must not replace a good copy
+	if err := json.Unmarshal(body, data); err != nil {
+		return fmt.Errorf("get %s - %s", uri, err)
+	}
+	if !unchanged {
+		if err := writeFileAtomic(file, body); err != nil {
+			return err
+		}
+	}
+	if !conditional {
+		return nil
+	}
+	return writeJSONFile(file+metaSuffix, mirrorMeta{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		SHA256:       hash,
+	})
+}
+
+// ErrNotModified is returned by an HTTPSource with SkipUnchanged set when the
+// entity has not changed since it was mirrored.
+var ErrNotModified = errors.New("not modified since last fetch")
+
+// FileSource reads entities from a directory laid out like the v1 API paths,
+// as written by HTTPSource with a Mirror.
+type FileSource string
+
+// ListFirms decodes a page of the firm list as mirrored by HTTPSource. A page
+// past the last one mirrored is empty, which ends the list.
+func (s FileSource) ListFirms(page int, list *Permalinks) error {
+	err := s.Get(listFile(page), 0, list)
+	if page > 0 && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s FileSource) Firm(permalink string, vc *VC) error {
+	return s.Get("financial-organization/"+permalink, 0, vc)
+}
+
+func (s FileSource) Company(permalink string, c *CompanyProfile) error {
+	return s.Get("company/"+permalink, 0, c)
+}
+
+func (s FileSource) Round(id string, r *Round) error {
+	return s.Get("funding-round/"+id, 0, r)
+}
+
+// Get decodes the file at path. The mirror holds a single page per entity, so
+// page is ignored.
+func (s FileSource) Get(path string, page int, data interface{}) error {
+	f, err := os.Open(string(s) + "/" + path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewDecoder(f).Decode(data)
+}
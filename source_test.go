@@ -0,0 +1,95 @@
+package fundhawk
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// crunchbaseServer serves a firm list of two pages, repeating the last one
+// like the API, and a firm per permalink with an ETag. Firms are answered
+// with 304 when the client sends the current ETag. It counts the 200s.
+func crunchbaseServer(t *testing.T, served *int32) *httptest.Server {
+	pages := []string{
+		`[{"permalink": "a"}, {"permalink": "b"}]`,
+		`[{"permalink": "c"}]`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".js")
+		if path == "financial-organizations" {
+			page := 0
+			fmt.Sscan(r.URL.Query().Get("page"), &page)
+			if page >= len(pages) {
+				page = len(pages) - 1
+			}
+			atomic.AddInt32(served, 1)
+			fmt.Fprint(w, pages[page])
+			return
+		}
+
+		permalink := strings.TrimPrefix(path, "financial-organization/")
+		etag := `"` + permalink + `-1"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		atomic.AddInt32(served, 1)
+		fmt.Fprintf(w, `{"name": %q, "permalink": %q, "investments": []}`, strings.ToUpper(permalink), permalink)
+	}))
+}
+
+// refresh does what -refresh does: enumerate the firms and fetch each one
+// into the mirror, skipping unchanged firms.
+func refresh(t *testing.T, server, mirror string) *Loader {
+	src := &HTTPSource{BaseURL: server + "/", Mirror: mirror, SkipUnchanged: true, Client: NewClient(0, 0, 0)}
+	list, err := FirmList(src, "", nil)
+	if err != nil {
+		t.Fatalf("FirmList: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("FirmList: got %d firms, want 3", len(list))
+	}
+	l := NewLoader(src, 2)
+	l.Load(list)
+	if n := l.Failures.Len(); n > 0 {
+		t.Fatalf("Load: %d firms failed", n)
+	}
+	return l
+}
+
+func TestRefreshUnchanged(t *testing.T) {
+	var served int32
+	s := crunchbaseServer(t, &served)
+	defer s.Close()
+	mirror := t.TempDir()
+
+	if l := refresh(t, s.URL, mirror); l.Unchanged() != 0 {
+		t.Errorf("first refresh: %d firms unchanged, want 0", l.Unchanged())
+	}
+	first := atomic.SwapInt32(&served, 0)
+
+	// the list is fetched again in full, the firms are not
+	if l := refresh(t, s.URL, mirror); l.Unchanged() != 3 {
+		t.Errorf("second refresh: %d firms unchanged, want 3", l.Unchanged())
+	}
+	if n := atomic.LoadInt32(&served); n != first-3 {
+		t.Errorf("second refresh: %d full responses, want %d", n, first-3)
+	}
+
+	// every page of the list is in the mirror
+	list, err := FirmList(FileSource(mirror), "", nil)
+	if err != nil {
+		t.Fatalf("FirmList from mirror: %v", err)
+	}
+	var got []string
+	for _, p := range list {
+		got = append(got, p.Link)
+	}
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("FirmList from mirror: got %v, want [a b c]", got)
+	}
+}
@@ -0,0 +1,177 @@
+package fundhawk
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Window is a span of recent history that a firm's metrics are also
+// computed over, so a change in strategy shows up. Months is 0 for all time.
+type Window struct {
+	Key    string
+	Name   string
+	Months int
+}
+
+var Windows = []Window{
+	{"all", "All time", 0},
+	{"5y", "Last 5 years", 60},
+	{"3y", "Last 3 years", 36},
+	{"12m", "Last 12 months", 12},
+}
+
+// WindowMetrics are a firm's distributions over the rounds it joined in one
+// Window. Since is the zero time for the all time window.
+type WindowMetrics struct {
+	Window
+	Since time.Time
+
+	Rounds    int
+	Companies int
+
+	RoundSizes  IntSlice
+	RoundShares IntSlice
+
+	SeriesDist        BucketedInts
+	RoundCountDist    BucketedInts
+	RaiseDist         BucketedInts
+	ShareDist         BucketedInts
+	InvestorRoundDist BucketedInts
+
+	PartnerList PartnerList
+}
+
+// windowRounds is what a window needs to know about a firm's rounds.
+type windowRounds struct {
+	codes      map[string]int64
+	companies  map[string]int64
+	partners   map[*VC]int
+	coinvestor map[string][]int64
+	rounds     int
+	sizes      IntSlice
+	shares     IntSlice
+}
+
+// calculateWindows computes every firm's WindowMetrics. The all time window
+// is the firm's own metrics, so it agrees with the totals above it on the
+// firm page. The others count the rounds with a known year up to MaxYear
+// from Months before the last round in the dataset.
+func (d *Dataset) calculateWindows() {
+	last := d.lastRoundDate()
+
+	for _, vc := range d.VCs {
+		vc.Windows = make([]*WindowMetrics, 0, len(Windows))
+	}
+
+	for _, w := range Windows {
+		if w.Months == 0 {
+			for _, vc := range d.VCs {
+				vc.Windows = append(vc.Windows, &WindowMetrics{
+					Window:            w,
+					Rounds:            len(vc.Investments),
+					Companies:         vc.TotalCompanies,
+					RoundSizes:        vc.RoundSizes,
+					RoundShares:       vc.RoundShares,
+					SeriesDist:        vc.SeriesDist,
+					RoundCountDist:    vc.RoundCountDist,
+					RaiseDist:         vc.RaiseDist,
+					ShareDist:         vc.ShareDist,
+					InvestorRoundDist: vc.InvestorRoundDist,
+					PartnerList:       vc.PartnerList,
+				})
+			}
+			continue
+		}
+
+		since := last.AddDate(0, -w.Months, 0)
+		rounds := make(map[*VC]*windowRounds, len(d.VCs))
+		for rid, vcs := range d.RoundVCs {
+			r := d.Rounds[rid]
+			if r.Year == nil || *r.Year > d.MaxYear || r.Date.Before(since) {
+				continue
+			}
+			for vc := range vcs {
+				wr, ok := rounds[vc]
+				if !ok {
+					wr = &windowRounds{
+						codes:      make(map[string]int64),
+						companies:  make(map[string]int64),
+						partners:   make(map[*VC]int),
+						coinvestor: make(map[string][]int64),
+					}
+					rounds[vc] = wr
+				}
+				wr.rounds++
+				wr.codes[r.Code]++
+				wr.companies[r.Company.Permalink]++
+				wr.coinvestor[r.Code] = append(wr.coinvestor[r.Code], int64(len(vcs))-1)
+				if r.Amount != nil && *r.Amount >= 1 {
+					wr.sizes = append(wr.sizes, int64(*r.Amount))
+					wr.shares = append(wr.shares, RoundInt(*r.Amount/float64(len(vcs))))
+				}
+				for v := range vcs {
+					if v != vc {
+						wr.partners[v]++
+					}
+				}
+			}
+		}
+
+		for _, vc := range d.VCs {
+			m := &WindowMetrics{Window: w, Since: since}
+			if wr, ok := rounds[vc]; ok {
+				m.fill(wr, rounds)
+			}
+			vc.Windows = append(vc.Windows, m)
+		}
+	}
+}
+
+// fill sets the window's metrics from the firm's rounds in it. all holds the
+// rounds of every firm, for the coinvestors' share of their own rounds.
+func (m *WindowMetrics) fill(wr *windowRounds, all map[*VC]*windowRounds) {
+	m.Rounds = wr.rounds
+	m.Companies = len(wr.companies)
+
+	m.RoundSizes, m.RoundShares = wr.sizes, wr.shares
+	m.RoundSizes.Sort()
+	m.RoundShares.Sort()
+	m.RaiseDist = RoundSizeBuckets.Aggregate(m.RoundSizes)
+	m.ShareDist = RoundShareBuckets.Aggregate(m.RoundShares)
+
+	cs := make([]int64, 0, len(wr.companies))
+	for _, n := range wr.companies {
+		cs = append(cs, n)
+	}
+	m.RoundCountDist = RoundCountBuckets.Aggregate(cs)
+
+	for _, b := range RoundCodeBuckets {
+		code := strings.ToLower(b)
+		if c, ok := wr.codes[code]; ok {
+			if c > m.SeriesDist.Max {
+				m.SeriesDist.Max = c
+			}
+			m.SeriesDist.Buckets = append(m.SeriesDist.Buckets, BucketedInt{b, c})
+		}
+		if ps, ok := wr.coinvestor[code]; ok {
+			c := RoundInt(Mean(ps))
+			if c > m.InvestorRoundDist.Max {
+				m.InvestorRoundDist.Max = c
+			}
+			m.InvestorRoundDist.Buckets = append(m.InvestorRoundDist.Buckets, BucketedInt{b, c})
+		}
+	}
+
+	m.PartnerList = make(PartnerList, 0, len(wr.partners))
+	for v, n := range wr.partners {
+		if n < 2 {
+			continue
+		}
+		p := &Partner{VC: v, Rounds: n}
+		p.Percentage = int64(math.Floor(float64(n) / float64(all[v].rounds) * 100))
+		m.PartnerList = append(m.PartnerList, p)
+	}
+	sort.Sort(m.PartnerList)
+}
@@ -0,0 +1,209 @@
+package fundhawk
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WorldMap is the backdrop of the dot map on the firm page: the 30 degree
+// graticule and a low resolution outline of the land, projected like the
+// dots. Land and Lakes are SVG path data.
+type WorldMap struct {
+	Width, Height        int
+	Parallels, Meridians []float64
+	Land, Lakes          string
+}
+
+var worldMap = newWorldMap()
+
+// World returns the dot map's backdrop.
+func World() *WorldMap {
+	return worldMap
+}
+
+func newWorldMap() *WorldMap {
+	m := &WorldMap{Width: MapWidth, Height: MapHeight}
+	for lat := 60; lat > -90; lat -= 30 {
+		_, y := projectPoint(0, float64(lat))
+		m.Parallels = append(m.Parallels, y)
+	}
+	for lon := -120; lon < 180; lon += 60 {
+		x, _ := projectPoint(float64(lon), 0)
+		m.Meridians = append(m.Meridians, x)
+	}
+	m.Land = outlinePath(landOutline)
+	m.Lakes = outlinePath(lakeOutline)
+	return m
+}
+
+// projectPoint maps a longitude and latitude onto the MapWidth by MapHeight
+// dot map.
+func projectPoint(lon, lat float64) (x, y float64) {
+	x = (lon + 180) / 360 * MapWidth
+	y = (90 - lat) / 180 * MapHeight
+	return RoundFloat(x, 1), RoundFloat(y, 1)
+}
+
+// outlinePath returns the SVG path data of closed polygons of longitude,
+// latitude pairs.
+func outlinePath(polygons [][]float64) string {
+	var b strings.Builder
+	for _, p := range polygons {
+		for i := 0; i+1 < len(p); i += 2 {
+			if i == 0 {
+				b.WriteByte('M')
+			} else {
+				b.WriteByte('L')
+			}
+			x, y := projectPoint(p[i], p[i+1])
+			b.WriteString(strconv.FormatFloat(x, 'f', -1, 64))
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatFloat(y, 'f', -1, 64))
+		}
+		b.WriteByte('Z')
+	}
+	return b.String()
+}
+
+// landOutline is a rough outline of the continents and the larger islands as
+// longitude, latitude pairs, good enough to place dots at the size of the
+// dot map.
+var landOutline = [][]float64{
+	// North America
+	{-168, 66, -162, 70, -156, 71.3, -141, 69.6, -128, 70, -115, 68, -95, 68.5, -94, 60, -82, 55, -79, 52, -77, 56,
+		-78, 62, -73, 62, -64, 60, -61, 56, -56, 52, -60, 47, -66, 44.5, -70, 42, -74, 40.5, -76, 37, -75.5, 35, -81, 31,
+		-80, 27, -80.5, 25.2, -82, 26.5, -83, 29.5, -85, 30, -89, 30, -94, 29.5, -97.2, 27, -97.5, 22, -96, 19, -94.5, 18.2,
+		-91, 19, -90.5, 21, -87, 21.5, -88, 18, -88, 16, -84, 15.5, -83.5, 11, -81.5, 9, -79, 9.5, -77.5, 8.5, -78, 7.5,
+		-80, 7.5, -83, 8.5, -85.7, 10, -87.5, 13, -91, 13.9, -94, 16, -96.5, 15.7, -100, 17, -105.5, 20, -105.6, 22.5,
+		-109.5, 26.5, -112.5, 29.5, -114.7, 31.7, -112, 28, -110, 24, -109.5, 23, -112, 24.8, -114.5, 28, -115.5, 30.5,
+		-117.1, 32.5, -118.5, 34, -120.6, 34.6, -122.5, 37.5, -124, 40.5, -124.5, 43, -124, 46.2, -124.7, 48.4, -123, 49,
+		-127.5, 50.5, -130, 54, -133, 57, -137, 58.5, -140, 59.7, -145, 60.3, -150, 59.5, -152, 57.5, -157, 56.5,
+		-163, 54.8, -158, 58, -162, 59.8, -165, 60.5, -166, 62, -164.5, 63.3, -161, 64.4, -166, 64.6, -168, 65.6},
+	// Baffin Island
+	{-80, 73.5, -72, 71.5, -67, 69, -62, 66.7, -65, 63, -68, 62.5, -72, 64, -77, 65, -74, 68, -80, 70, -88, 70, -90, 72.5,
+		-85, 73.8},
+	// Victoria Island
+	{-118, 72.5, -104, 73.3, -101, 70, -112, 68.5, -119, 71},
+	// Ellesmere Island
+	{-90, 77, -75, 78, -62, 82, -70, 83, -90, 81.5, -95, 79},
+	// Greenland
+	{-73, 78, -66, 76, -58, 75.5, -55, 71, -52, 68, -50, 64, -44, 60, -42, 61, -38, 65.5, -32, 68, -22, 70.5, -20, 75,
+		-18, 78, -12, 81.5, -30, 83.5, -45, 82.5, -60, 82, -65, 81, -70, 79.5},
+	// Iceland
+	{-22.5, 63.9, -24, 65.5, -22, 66.4, -18, 66.2, -14.5, 66.3, -13.5, 65, -15, 64.3, -18.5, 63.4},
+	// Cuba
+	{-84.9, 21.9, -82, 23.1, -77, 22.2, -74.2, 20.2, -77.7, 19.9, -78.5, 21.5, -82, 22.2},
+	// Hispaniola
+	{-74.4, 18.5, -72.8, 19.9, -70, 19.7, -68.4, 18.6, -70, 18.2, -71.4, 17.6},
+	// South America
+	{-77.5, 8.5, -75.5, 10.5, -72, 11.8, -71.5, 10.5, -68, 10.5, -62, 10.7, -60, 8.5, -57, 6, -52, 4.8, -50, 1.8, -50, -0.5,
+		-44.5, -2.5, -40, -2.8, -35, -5.5, -35, -9, -37, -11.5, -39, -13.5, -39, -17.5, -40.5, -21, -42, -23, -44.5, -23.3,
+		-48.5, -26, -48.7, -28.5, -51, -31.5, -53.5, -34, -55, -35, -57.5, -35.3, -57, -37, -58, -38.5, -62, -39,
+		-62.3, -40.8, -65, -41, -64.5, -42.5, -65.5, -45, -67.5, -46.5, -66, -48, -68.5, -50.5, -68.5, -52.3, -70, -53,
+		-67, -55, -71, -55, -74.5, -52.5, -75.5, -48, -74, -44, -73.5, -41, -73.5, -37, -71.5, -32, -71.4, -28,
+		-70.5, -23.5, -70.2, -18.5, -75.5, -15, -77.5, -12, -79, -8, -81.2, -5.5, -80.3, -3.4, -80.5, -1, -80, 1,
+		-78.8, 1.8, -77.5, 4, -77.3, 7},
+	// Great Britain
+	{-5.7, 50, -3, 50.6, 1.4, 51.2, 1.7, 52.7, 0.2, 53.5, -0.5, 54.5, -1.6, 55.6, -2, 57, -1.8, 57.6, -3.2, 58.6, -5, 58.6,
+		-6.2, 57.4, -5.6, 55.3, -4.6, 54.8, -3.2, 54.9, -3, 53.4, -4.5, 52.9, -4, 51.6, -5.3, 51.7},
+	// Ireland
+	{-6, 52.2, -6.1, 53.9, -5.5, 54.6, -7.2, 55.3, -8.4, 54.6, -10, 54.2, -9.8, 53.4, -10.3, 52, -9.4, 51.5, -8, 51.8},
+	// Eurasia
+	{-5.6, 36, -2, 36.7, 0.5, 38.5, 0, 39.8, 3.2, 42, 3.1, 43, 6, 43.1, 8.7, 44.4, 10.5, 43, 12.3, 41.7, 14, 40.8,
+		15.6, 40, 15.7, 38, 17.1, 39, 16.6, 40.3, 18.5, 40.2, 17, 41, 16, 41.9, 14, 42.6, 12.4, 44.3, 12.3, 45.2, 13.7, 45.6,
+		15, 45, 18.5, 42.5, 19.5, 41.8, 20.2, 39.7, 21.5, 37, 22.8, 36.5, 23.3, 38, 24, 40.7, 26, 40.8, 26.5, 40.1,
+		26.8, 38.5, 27.5, 37, 28.5, 36.7, 30.5, 36.3, 32.8, 36.1, 36, 36.6, 35.9, 35, 35, 33, 34.3, 31.3, 34, 29.5,
+		34.9, 29.5, 35.8, 27, 38.5, 23.5, 39.2, 21.5, 41, 18, 42.8, 15, 43.3, 12.7, 45, 12.8, 48.5, 14, 52, 15.7,
+		55.5, 17.8, 57, 18.9, 58.5, 20.5, 59.8, 22.5, 58.5, 23.7, 56.5, 24.5, 56.3, 26.3, 55, 25.5, 52, 24, 51.5, 25.2,
+		50.8, 24.8, 50.2, 26.3, 48.5, 28.4, 48, 30, 50, 30.2, 51.5, 27.8, 54.5, 26.6, 57, 27.1, 58.8, 25.5, 61.6, 25.2,
+		66.5, 25.4, 67.5, 24, 68.8, 22.5, 70, 21, 72.8, 21, 73, 19, 73.5, 16, 74.5, 13.5, 76, 10, 77.5, 8, 78, 9,
+		79.9, 10.3, 80.2, 13.5, 80, 15.5, 82.3, 16.6, 84.5, 19, 87, 21.5, 89, 21.8, 91, 22.6, 92, 21, 93, 19.5, 94.3, 18,
+		94.3, 16, 97.6, 16.5, 98.2, 13, 98.6, 10, 98.5, 8.2, 100.3, 6.3, 101, 3, 103.5, 1.4, 104.2, 1.4, 103.4, 4.4,
+		102.2, 6.2, 100.5, 7.3, 99.9, 9.2, 99.2, 10.5, 100, 13.5, 100.9, 12.7, 102.5, 12.2, 104.8, 10.5, 104.7, 8.8,
+		106.5, 9.5, 109, 11.5, 109.3, 13.5, 108.3, 16, 106.5, 18, 105.7, 19, 106.7, 20.5, 108, 21.5, 110, 21, 111.5, 21.5,
+		114, 22.3, 116.5, 22.9, 119.5, 25, 121.5, 28, 121.9, 30.8, 120.8, 32, 119.2, 34.5, 120.5, 36.2, 122.5, 37,
+		121, 37.8, 118.8, 37.5, 117.7, 38.8, 119.5, 39.8, 121.3, 40.9, 122.2, 40.4, 121.2, 39, 123.5, 39.8, 125.3, 39.5,
+		126.5, 37.6, 126.5, 35, 129.3, 35.2, 129.5, 36.7, 128.4, 38.7, 127.5, 39.8, 129.7, 40.9, 130.8, 42.5, 133, 42.8,
+		135.5, 43.9, 138.5, 47, 140.5, 50, 140.8, 53, 137.5, 54, 135.3, 54.7, 138.5, 56.5, 142, 59, 146, 59.4, 151, 59.1,
+		155, 59.3, 156.5, 57.5, 156.7, 51, 158.5, 52.9, 160, 54.5, 163, 56.2, 162, 58, 164.5, 59.8, 170, 60, 173, 61.7,
+		178, 62.5, 179.9, 65, 179.9, 68.9, 175, 69.8, 170, 70, 161, 69.5, 152, 70.9, 146, 72.3, 140, 72.5, 130, 71,
+		128, 72.8, 122, 73, 113, 73.7, 110, 76.7, 104, 77.7, 98, 76, 89, 75.5, 80, 73.6, 80.5, 72.3, 78, 72.1, 75, 72.8,
+		72.5, 72.8, 72.8, 71, 73.5, 68.5, 69, 66.8, 66.5, 69, 61, 69.7, 58, 68.5, 54, 68.8, 48.3, 67.8, 44, 68.5,
+		43.5, 66.4, 41, 66.2, 40.5, 64.6, 37, 63.8, 35, 64.4, 33, 66.6, 38, 66.1, 41, 67.5, 39, 68.3, 33, 69.4, 29, 70,
+		26, 71, 21, 70.2, 16, 68.5, 13, 66, 12, 64, 8, 63.2, 5, 62, 5.5, 59, 7, 58, 8.6, 58, 10.5, 59.3, 11, 58.9,
+		11.8, 57.7, 12.7, 56, 14.3, 55.5, 16, 56.2, 16.6, 57.5, 18.2, 59.5, 17.3, 61, 17.8, 62.5, 21, 64.5, 22.2, 65.8,
+		25.4, 65.2, 25, 64, 21.5, 62.6, 21.4, 60.6, 23, 60, 26.5, 60.4, 29.5, 60, 28, 59.5, 23.5, 59.2, 24, 57.5, 21, 56.8,
+		21, 55.3, 19.6, 54.4, 18, 54.8, 14.2, 53.9, 12.3, 54.2, 10.9, 54, 10, 54.9, 10.5, 57.6, 8.5, 57.1, 8.1, 55.5,
+		8.8, 54, 7, 53.5, 5, 53.3, 3.8, 51.5, 2.5, 51.1, 1.6, 50.2, 0, 49.5, -1.3, 49.6, -1.7, 48.6, -4.6, 48.5, -4.4, 47.8,
+		-2, 47, -1.2, 46, -1.5, 43.4, -4, 43.5, -8, 43.7, -9.3, 43, -8.9, 41, -9.5, 38.7, -8.9, 37, -7.4, 37.2, -6.3, 36.8},
+	// Svalbard
+	{11, 78.5, 17, 80.3, 27, 80, 22, 78, 16, 76.6},
+	// Novaya Zemlya
+	{52, 71.5, 56, 74, 62, 76.5, 68, 76.8, 58, 74.2, 55, 71.5},
+	// Sakhalin
+	{142, 46, 143.5, 49, 143, 53, 142.5, 54.3, 142, 52, 141.8, 48},
+	// Honshu, Shikoku and Kyushu
+	{130.2, 31.3, 129.6, 33.3, 131, 34.4, 132.5, 35.4, 135.5, 35.6, 136.8, 37.3, 139.2, 38.2, 140, 40, 140, 41.5,
+		141.5, 41.4, 142, 39, 141, 37, 140.8, 35.6, 139.8, 35, 138.8, 34.6, 137, 34.6, 135.3, 33.7, 134.2, 33.4,
+		133, 32.7, 132, 33, 131.5, 31.4},
+	// Hokkaido
+	{140, 42, 141.3, 45.4, 142.5, 44.4, 145.5, 43.3, 143.3, 42, 141.5, 42.6},
+	// Taiwan
+	{120.2, 23, 121.5, 25.3, 122, 24.5, 120.8, 22},
+	// Luzon
+	{120, 18.5, 122.3, 18.5, 122, 16, 124, 13, 121.5, 13.8, 120.5, 14.5, 119.8, 16.3},
+	// Mindanao
+	{122, 7, 125.5, 9.8, 126.5, 7, 125.5, 5.8, 124, 6.5},
+	// Sri Lanka
+	{79.8, 6.5, 80.2, 9.8, 81.8, 7.5, 81, 6},
+	// Sumatra
+	{95.3, 5.6, 97.5, 5.2, 100.5, 2, 104, -1, 106, -3, 105.8, -5.8, 104.5, -5.9, 102.3, -4, 100.3, -1, 98.7, 1.7},
+	// Java
+	{105.2, -6.8, 106, -5.9, 108.3, -6.3, 110.5, -6.9, 112.7, -6.9, 114.5, -7.8, 114.4, -8.6, 110.5, -8.2, 106.5, -7.4},
+	// Borneo
+	{109, 1.5, 109.6, -1.3, 110.3, -2.9, 114, -3.5, 116.3, -3.9, 116.5, -1.5, 118, 1, 118.5, 4.5, 117, 7, 116, 6.5,
+		114.5, 4.5, 113, 3.1, 111.2, 2.4, 109.6, 2},
+	// Sulawesi
+	{119.5, -5.5, 120.4, -5.6, 121, -2.7, 123.3, -1, 121, -1, 120.6, 0.5, 124.9, 1.5, 120.3, 1, 119.5, -0.3, 118.8, -2.7},
+	// New Guinea
+	{131, -1.5, 134, -0.8, 138, -1.6, 141, -2.6, 145, -4.5, 146, -6.5, 148, -8.5, 150.5, -10.5, 147.5, -10, 146, -8.2,
+		144, -7.7, 143, -9, 141, -9.1, 139, -8, 138, -5.5, 135, -4.3, 132.8, -4, 132, -2.8},
+	// Africa
+	{-17, 21, -16.5, 24, -13.5, 27.5, -9.8, 29.8, -9.5, 32.5, -6.8, 34, -5.9, 35.8, -2, 35.1, 1, 36.5, 6, 37, 10, 37.3,
+		11, 35.2, 10.3, 34, 11.5, 33, 15, 32.3, 19.5, 30.5, 20, 32, 23, 32.6, 25, 31.6, 29, 30.8, 32.3, 31.3, 34.2, 31.2,
+		34, 27.8, 35.5, 23.8, 37.2, 21, 38.5, 18, 39.5, 15.5, 42, 13, 43.3, 11.7, 44.5, 10.4, 47, 11.1, 51.2, 11.8,
+		51, 10.4, 49, 6, 46, 2, 43, -0.5, 41, -2, 39.5, -5, 39, -7, 39.8, -10, 40.5, -15, 37, -17.5, 35, -21, 35.5, -24,
+		32.8, -26, 32.5, -28.6, 30, -31.3, 27, -33.6, 22.5, -34, 20, -34.8, 18.4, -34, 18, -32, 16.5, -28.6, 15, -26.5,
+		14.4, -22.5, 11.8, -17.5, 12.3, -13.5, 13.7, -11, 12.2, -6, 11.8, -3.5, 9.3, -0.7, 9.8, 3, 8.5, 4.5, 5.7, 4.3,
+		4.5, 6.3, 2, 6.3, -2, 4.8, -4.5, 5.2, -7.5, 4.4, -9.5, 5.3, -11.5, 6.8, -13.3, 8.5, -15, 10.8, -16.7, 12.5,
+		-17.3, 14.7, -16.5, 16.5, -16, 19},
+	// Madagascar
+	{49.3, -12, 50.5, -15.5, 49.5, -17.5, 48, -22, 47, -25, 45, -25.5, 43.6, -23, 43.5, -21, 44.3, -17, 46.5, -15.5,
+		48, -14},
+	// Australia
+	{113.5, -22, 114.2, -26, 115, -30, 115, -34, 118, -35, 123.5, -33.9, 126, -32.3, 131, -31.5, 134, -32.7,
+		135.9, -34.8, 138, -33, 138.4, -35, 140, -37.5, 143.5, -38.8, 146.3, -39, 150, -37.5, 150.5, -35, 153, -31,
+		153.5, -28, 153, -25, 150.8, -22.5, 149, -20.5, 146.5, -19, 145.3, -15, 143.5, -14, 142.5, -10.7, 141.5, -13.5,
+		141.5, -16.5, 140.5, -17.7, 139, -16.6, 135.5, -15, 136.8, -12.3, 132.5, -11.5, 130, -12.5, 129.5, -15,
+		127.5, -14, 125, -15, 122.2, -17.5, 121, -19.5, 117, -20.6},
+	// Tasmania
+	{144.6, -40.7, 148.3, -40.9, 148, -43.2, 146, -43.6},
+	// New Zealand
+	{172.7, -34.4, 174.6, -36.2, 176, -37.6, 178.5, -37.7, 177, -39.3, 176.8, -40.3, 175.3, -41.6, 174.5, -39.8,
+		173.8, -39.2, 174.6, -37.3},
+	{172.7, -40.5, 174.3, -41.4, 173.3, -43, 171.2, -44.4, 169.3, -46.6, 166.5, -46, 168, -44, 170.8, -42.5},
+	// Antarctica
+	{-180, -78, -160, -78, -150, -76, -130, -74, -100, -73, -80, -73, -70, -68, -57, -63.5, -60, -70, -62, -74, -45, -78,
+		-30, -76, -10, -71, 10, -70, 30, -69.5, 50, -67, 70, -68, 80, -67, 100, -66, 120, -66.5, 140, -66.8, 160, -70,
+		170, -72, 180, -78, 180, -90, -180, -90},
+}
+
+// lakeOutline holds the inland seas inside landOutline, drawn as water.
+var lakeOutline = [][]float64{
+	// Black Sea
+	{28, 41.2, 29, 41.1, 31.5, 41.2, 35, 42, 38.5, 40.9, 41.5, 41.5, 41.7, 42.8, 39.8, 43.5, 37.5, 44.7, 36.5, 45.3,
+		35, 45, 33.5, 44.5, 32.6, 45.4, 33.5, 46.1, 31.5, 46.6, 30.2, 45.8, 29.6, 45.3, 28.7, 44.3, 28, 42.5},
+	// Caspian Sea
+	{47, 45, 49.2, 46.4, 51.2, 47.1, 53, 46.8, 53.2, 45.3, 51.3, 45, 51, 44, 52.7, 42.3, 53, 40.5, 54, 37.4, 51, 36.7,
+		49, 37.6, 49.5, 40.2, 48.5, 41.8, 47.5, 43},
+}